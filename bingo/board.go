@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// data model, same as in day4
+type board struct {
+	numbers         [5][5]int
+	marked          [5][5]bool
+	markedInRows    [5]int
+	markedInColumns [5]int
+}
+
+// mark returns false if the number isn't on the board, or if it's already marked, e.g. when drawn twice
+func (b *board) mark(number int) (int, int, bool) {
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 5; j++ {
+			if b.numbers[i][j] == number && !b.marked[i][j] {
+				b.marked[i][j] = true
+				b.markedInRows[i]++
+				b.markedInColumns[j]++
+				return i, j, true
+			}
+		}
+	}
+	return -1, -1, false
+}
+
+func (b *board) sumUnmarked() int {
+	var sum int
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 5; j++ {
+			if !b.marked[i][j] {
+				sum += b.numbers[i][j]
+			}
+		}
+	}
+	return sum
+}
+
+// format returns the 25 numbers row by row, separated by spaces
+func (b *board) format() string {
+	xs := make([]string, 0, 25)
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 5; j++ {
+			xs = append(xs, strconv.Itoa(b.numbers[i][j]))
+		}
+	}
+	return strings.Join(xs, " ")
+}
+
+// boring input read, same as in day4
+func read(r io.Reader) ([]int, []*board) {
+	numbers := make([]int, 0)
+	boards := make([]*board, 0)
+
+	s := bufio.NewScanner(r)
+
+	// Let's get the numbers first
+	if !s.Scan() {
+		log.Fatalf("No input\n")
+	}
+	for _, x := range strings.Split(s.Text(), ",") {
+		n, err := strconv.Atoi(x)
+		if err != nil {
+			log.Fatalf("Can't convert item [%s] to int\n", x)
+		}
+		numbers = append(numbers, n)
+	}
+
+	// Now the boards
+	for {
+		// new line
+		if !s.Scan() {
+			break
+		}
+		var numbers [5][5]int
+		// 5 lines of 5 numbers
+		for i := 0; i < 5; i++ {
+			if !s.Scan() {
+				log.Fatalf("Expected another line of the board")
+			}
+
+			j := 0
+			for _, x := range strings.Split(s.Text(), " ") {
+				if x == "" {
+					// extra space in the input
+					continue
+				}
+				n, err := strconv.Atoi(x)
+				if err != nil {
+					log.Fatalf("Can't convert item [%s] to int\n", x)
+				}
+				numbers[i][j] = n
+				j++
+			}
+		}
+
+		boards = append(boards, &board{numbers: numbers})
+	}
+
+	if err := s.Err(); err != nil {
+		log.Fatalf("Scanner errors: %v\n", err)
+	}
+
+	return numbers, boards
+}
+
+// parseBoard reads the numbers sent in a BOARD message
+func parseBoard(xs []string) (*board, error) {
+	if len(xs) != 25 {
+		return nil, fmt.Errorf("want 25 numbers, got %d", len(xs))
+	}
+	var b board
+	for k, x := range xs {
+		n, err := strconv.Atoi(x)
+		if err != nil {
+			return nil, fmt.Errorf("can't convert item [%s] to int: %w", x, err)
+		}
+		b.numbers[k/5][k%5] = n
+	}
+	return &b, nil
+}
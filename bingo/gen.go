@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type genConfig struct {
+	boards      int
+	draws       int
+	min, max    int
+	uniqueDraws bool
+	uniqueCells bool
+}
+
+// gen writes a puzzle in the day4 input format to stdout
+func gen(args []string) {
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	var cfg genConfig
+	fs.IntVar(&cfg.boards, "boards", 100, "number of boards")
+	fs.IntVar(&cfg.draws, "draws", -1, "number of draws, defaults to every number in the range")
+	fs.IntVar(&cfg.min, "min", 0, "smallest number")
+	fs.IntVar(&cfg.max, "max", 99, "largest number")
+	fs.BoolVar(&cfg.uniqueDraws, "unique-draws", true, "never draw the same number twice")
+	fs.BoolVar(&cfg.uniqueCells, "unique-cells", true, "never repeat a number on a single board")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "random seed, the same seed gives the same puzzle")
+	_ = fs.Parse(args)
+
+	if cfg.draws < 0 {
+		cfg.draws = cfg.max - cfg.min + 1
+	}
+	if err := cfg.validate(); err != nil {
+		log.Fatalf("Can't generate: %v\n", err)
+	}
+
+	var buf bytes.Buffer
+	cfg.generate(&buf, rand.New(rand.NewSource(*seed)))
+
+	// make sure that read accepts what we've generated
+	numbers, boards := read(bytes.NewReader(buf.Bytes()))
+	if len(numbers) != cfg.draws || len(boards) != cfg.boards {
+		log.Fatalf("Generated %d draws and %d boards, read back %d and %d\n",
+			cfg.draws, cfg.boards, len(numbers), len(boards))
+	}
+
+	if _, err := io.Copy(os.Stdout, &buf); err != nil {
+		log.Fatalf("Can't write the puzzle: %v\n", err)
+	}
+}
+
+func (cfg genConfig) validate() error {
+	size := cfg.max - cfg.min + 1
+	switch {
+	case cfg.min < 0:
+		return fmt.Errorf("numbers have to be non-negative, got -min %d", cfg.min)
+	case size < 1:
+		return fmt.Errorf("empty range [%d, %d]", cfg.min, cfg.max)
+	case cfg.boards < 1:
+		return fmt.Errorf("need at least one board, got %d", cfg.boards)
+	case cfg.draws < 1:
+		return fmt.Errorf("need at least one draw, got %d", cfg.draws)
+	case cfg.uniqueDraws && cfg.draws > size:
+		return fmt.Errorf("can't draw %d unique numbers from [%d, %d]", cfg.draws, cfg.min, cfg.max)
+	case cfg.uniqueCells && size < 25:
+		return fmt.Errorf("can't fill a board with 25 unique numbers from [%d, %d]", cfg.min, cfg.max)
+	}
+	return nil
+}
+
+func (cfg genConfig) generate(w io.Writer, rnd *rand.Rand) {
+	draws := cfg.pick(rnd, cfg.draws, cfg.uniqueDraws)
+	xs := make([]string, 0, len(draws))
+	for _, n := range draws {
+		xs = append(xs, strconv.Itoa(n))
+	}
+	fmt.Fprintln(w, strings.Join(xs, ","))
+
+	// right-align the numbers, like in the puzzle input
+	width := len(strconv.Itoa(cfg.max))
+	for k := 0; k < cfg.boards; k++ {
+		fmt.Fprintln(w)
+		cells := cfg.pick(rnd, 25, cfg.uniqueCells)
+		for i := 0; i < 5; i++ {
+			row := make([]string, 0, 5)
+			for j := 0; j < 5; j++ {
+				row = append(row, fmt.Sprintf("%*d", width, cells[i*5+j]))
+			}
+			fmt.Fprintln(w, strings.Join(row, " "))
+		}
+	}
+}
+
+// pick returns n numbers from [min, max], each one only once if unique
+func (cfg genConfig) pick(rnd *rand.Rand, n int, unique bool) []int {
+	size := cfg.max - cfg.min + 1
+	xs := make([]int, 0, n)
+	if !unique {
+		for i := 0; i < n; i++ {
+			xs = append(xs, cfg.min+rnd.Intn(size))
+		}
+		return xs
+	}
+
+	// partial Fisher-Yates, keeping only the swapped positions to support huge ranges
+	swapped := make(map[int]int, n)
+	at := func(i int) int {
+		if v, ok := swapped[i]; ok {
+			return v
+		}
+		return i
+	}
+	for i := 0; i < n; i++ {
+		j := i + rnd.Intn(size-i)
+		vi, vj := at(i), at(j)
+		swapped[i], swapped[j] = vj, vi
+		xs = append(xs, cfg.min+vj)
+	}
+	return xs
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"time"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "serve":
+		serve(os.Args[2:])
+	case "gen":
+		gen(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: bingo <command> [flags] [input file]\n\n")
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	fmt.Fprintf(os.Stderr, "  serve  host a game over TCP, see PROTOCOL.md\n")
+	fmt.Fprintf(os.Stderr, "  gen    generate a puzzle in the day4 input format\n")
+	os.Exit(2)
+}
+
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:7070", "address to listen on")
+	interval := fs.Duration("interval", 2*time.Second, "time between the draws")
+	_ = fs.Parse(args)
+
+	reader, closer := selectInput(fs)
+	numbers, boards := read(reader)
+	closer()
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("Can't listen on %s: %v\n", *addr, err)
+	}
+	log.Printf("Serving %d boards on %s\n", len(boards), ln.Addr())
+
+	newServer(numbers, boards, *interval).serve(ln)
+}
+
+func selectInput(fs *flag.FlagSet) (reader io.Reader, closer func()) {
+	if fs.NArg() > 0 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			log.Fatalf("Can't open %s: %v\n", fs.Arg(0), err)
+		}
+		return f, func() {
+			_ = f.Close()
+		}
+	}
+	return os.Stdin, func() {
+		// do nothing
+	}
+}
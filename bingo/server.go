@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// server runs a single game, see PROTOCOL.md for the messages
+type server struct {
+	numbers  []int
+	boards   []*board
+	interval time.Duration
+
+	mu      sync.Mutex
+	players map[*player]bool
+	owners  []*player // owners[k] has claimed boards[k]
+	scores  []int     // scores[k] is set once boards[k] completes a line
+	won     []bool
+	drawn   int
+	started bool
+	over    bool
+	done    chan struct{}
+}
+
+type player struct {
+	name  string
+	board int // -1 until a board is claimed
+	out   chan string
+}
+
+func newServer(numbers []int, boards []*board, interval time.Duration) *server {
+	return &server{
+		numbers:  numbers,
+		boards:   boards,
+		interval: interval,
+		players:  map[*player]bool{},
+		owners:   make([]*player, len(boards)),
+		scores:   make([]int, len(boards)),
+		won:      make([]bool, len(boards)),
+		done:     make(chan struct{}),
+	}
+}
+
+// serve accepts the players until the game is over, then waits for the last messages to be delivered
+func (s *server) serve(ln net.Listener) {
+	var (
+		handlers sync.WaitGroup
+		connsMu  sync.Mutex
+		conns    []net.Conn
+	)
+
+	go func() {
+		<-s.done
+		_ = ln.Close()
+		// stop reading, so that the handlers flush what's left and hang up
+		connsMu.Lock()
+		for _, conn := range conns {
+			if c, ok := conn.(interface{ CloseRead() error }); ok {
+				_ = c.CloseRead()
+			}
+		}
+		connsMu.Unlock()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				handlers.Wait()
+				return
+			default:
+				log.Fatalf("Can't accept a connection: %v\n", err)
+			}
+		}
+		connsMu.Lock()
+		conns = append(conns, conn)
+		select {
+		case <-s.done:
+			// accepted while the game was finishing
+			if c, ok := conn.(interface{ CloseRead() error }); ok {
+				_ = c.CloseRead()
+			}
+		default:
+		}
+		connsMu.Unlock()
+
+		handlers.Add(1)
+		go func() {
+			defer handlers.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *server) handle(conn net.Conn) {
+	p := &player{board: -1, out: make(chan string, len(s.numbers)+16)}
+	s.mu.Lock()
+	s.players[p] = true
+	s.mu.Unlock()
+
+	// writer
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		w := bufio.NewWriter(conn)
+		for msg := range p.out {
+			_, _ = w.WriteString(msg + "\n")
+			if len(p.out) == 0 {
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+		_ = w.Flush()
+	}()
+
+	// reader
+	r := bufio.NewScanner(conn)
+	for r.Scan() {
+		if quit := s.command(p, strings.Fields(r.Text())); quit {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	delete(s.players, p)
+	if p.board >= 0 {
+		s.owners[p.board] = nil
+	}
+	close(p.out)
+	s.mu.Unlock()
+
+	<-written
+	_ = conn.Close()
+}
+
+// command handles a single line sent by the player, returns true if the connection should be closed
+func (s *server) command(p *player, xs []string) bool {
+	if len(xs) == 0 {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch strings.ToUpper(xs[0]) {
+	case "HELLO":
+		if len(xs) != 2 {
+			p.send("ERR usage: HELLO <name>")
+			return false
+		}
+		p.name = xs[1]
+		p.send("WELCOME %s", p.name)
+
+	case "LIST":
+		free := []string{"FREE"}
+		for k, owner := range s.owners {
+			if owner == nil {
+				free = append(free, strconv.Itoa(k))
+			}
+		}
+		p.send("%s", strings.Join(free, " "))
+
+	case "CLAIM":
+		if p.name == "" {
+			p.send("ERR say HELLO first")
+			return false
+		}
+		if len(xs) != 2 {
+			p.send("ERR usage: CLAIM <board>")
+			return false
+		}
+		k, err := strconv.Atoi(xs[1])
+		if err != nil || k < 0 || k >= len(s.boards) {
+			p.send("ERR no such board %s", xs[1])
+			return false
+		}
+		if p.board >= 0 {
+			p.send("ERR already playing board %d", p.board)
+			return false
+		}
+		if s.owners[k] != nil {
+			p.send("ERR board %d taken by %s", k, s.owners[k].name)
+			return false
+		}
+		s.owners[k] = p
+		p.board = k
+		p.send("BOARD %d %s", k, s.boards[k].format())
+
+	case "START":
+		if s.started {
+			p.send("ERR already started")
+			return false
+		}
+		s.started = true
+		go s.draw()
+
+	case "BINGO":
+		if p.board < 0 {
+			p.send("ERR no board claimed")
+			return false
+		}
+		if s.over {
+			p.send("ERR game over")
+			return false
+		}
+		if !s.won[p.board] {
+			p.send("ERR no bingo on board %d", p.board)
+			return false
+		}
+		s.broadcast("WIN %s %d %d", p.name, p.board, s.scores[p.board])
+		s.finish()
+
+	case "QUIT":
+		p.send("BYE")
+		return true
+
+	default:
+		p.send("ERR unknown command %s", xs[0])
+	}
+	return false
+}
+
+// draw broadcasts the numbers one by one, marking all the boards.
+// The score of a board is fixed by the draw which completes its line, so a late BINGO call doesn't change it.
+func (s *server) draw() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		if s.over {
+			s.mu.Unlock()
+			return
+		}
+		if s.drawn == len(s.numbers) {
+			s.finish()
+			s.mu.Unlock()
+			return
+		}
+		n := s.numbers[s.drawn]
+		s.drawn++
+		for k, b := range s.boards {
+			if s.won[k] {
+				continue
+			}
+			i, j, ok := b.mark(n)
+			if !ok {
+				continue
+			}
+			if b.markedInRows[i] == 5 || b.markedInColumns[j] == 5 {
+				s.won[k] = true
+				s.scores[k] = b.sumUnmarked() * n
+			}
+		}
+		s.broadcast("DRAW %d", n)
+		s.mu.Unlock()
+	}
+}
+
+// finish ends the game, expects the lock to be held
+func (s *server) finish() {
+	s.over = true
+	s.broadcast("END")
+	close(s.done)
+}
+
+// broadcast sends the message to all the players, expects the lock to be held
+func (s *server) broadcast(format string, args ...interface{}) {
+	for p := range s.players {
+		p.send(format, args...)
+	}
+}
+
+func (p *player) send(format string, args ...interface{}) {
+	select {
+	case p.out <- fmt.Sprintf(format, args...):
+	default:
+		// the player doesn't keep up with the game, drop the message
+	}
+}
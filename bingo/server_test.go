@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// the day4 example, board 2 wins with the score of 4512
+const gameInput = `7,4,9,5,11,17,23,2,0,14,21,24,10,16,13,6,15,25,12,22,18,20,8,19,3,26,1
+
+22 13 17 11  0
+ 8  2 23  4 24
+21  9 14 16  7
+ 6 10  3 18  5
+ 1 12 20 15 19
+
+ 3 15  0  2 22
+ 9 18 13 17  5
+19  8  7 25 23
+20 11 10 24  4
+14 21 16 12  6
+
+14 21 17 24  4
+10 16 15  9 19
+18  8 23 26 20
+22 11 13  6  5
+ 2  0 12  3  7
+`
+
+// TestGame starts a server on localhost and plays a scripted game with fake clients
+func TestGame(t *testing.T) {
+	numbers, boards := read(strings.NewReader(gameInput))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Can't listen on localhost: %v", err)
+	}
+	served := make(chan struct{})
+	go func() {
+		newServer(numbers, boards, 10*time.Millisecond).serve(ln)
+		close(served)
+	}()
+	addr := ln.Addr().String()
+
+	alice := dial(t, addr, "alice")
+	bob := dial(t, addr, "bob")
+	carol := dial(t, addr, "carol")
+	eve := dial(t, addr, "eve")
+
+	// list the free boards
+	alice.send("LIST")
+	alice.expect("FREE 0 1 2")
+
+	// claim the boards
+	alice.claim(2)
+	carol.send("CLAIM 2")
+	carol.expect("ERR board 2 taken by alice")
+	bob.claim(0)
+	carol.claim(1)
+	eve.send("CLAIM 3")
+	eve.expect("ERR no such board 3")
+
+	// reject a BINGO without a board
+	eve.send("BINGO")
+	eve.expect("ERR no board claimed")
+
+	// reject a false BINGO
+	bob.send("BINGO")
+	bob.expect("ERR no bingo on board 0")
+
+	// play the game, every client sees the same winner
+	alice.send("START")
+	results := make(chan result)
+	for _, c := range []*fakeClient{alice, bob, carol, eve} {
+		go func(c *fakeClient) {
+			win, err := c.play()
+			results <- result{c.name, win, err}
+		}(c)
+	}
+	for i := 0; i < 4; i++ {
+		r := <-results
+		if r.err != nil {
+			t.Fatalf("%s: %v", r.name, r.err)
+		}
+		if r.win != "WIN alice 2 4512" {
+			t.Fatalf("%s wants [WIN alice 2 4512], got [%s]", r.name, r.win)
+		}
+	}
+
+	// shut down the server
+	for _, c := range []*fakeClient{alice, bob, carol, eve} {
+		_ = c.conn.Close()
+	}
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Server still running after the game")
+	}
+}
+
+// TestRepeatedDraws makes sure a number drawn again doesn't mark a cell again
+func TestRepeatedDraws(t *testing.T) {
+	// the first board of the example, the first row wins on the last draw
+	numbers, boards := read(strings.NewReader("22,22,22,22,22,13,17,0,11\n\n" + strings.Split(gameInput, "\n\n")[1]))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Can't listen on localhost: %v", err)
+	}
+	go newServer(numbers, boards, 10*time.Millisecond).serve(ln)
+
+	mallory := dial(t, ln.Addr().String(), "mallory")
+	defer func() {
+		_ = mallory.conn.Close()
+	}()
+	mallory.claim(0)
+	mallory.send("START")
+
+	draws := 0
+	for {
+		msg, err := mallory.receive()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		switch {
+		case strings.HasPrefix(msg, "DRAW"):
+			draws++
+			if draws == 5 || draws == len(numbers) {
+				// 22 five times isn't a bingo, the whole row is
+				mallory.send("BINGO")
+			}
+		case msg == "ERR no bingo on board 0":
+			if draws >= len(numbers) {
+				t.Fatalf("The completed row isn't a bingo")
+			}
+		case msg == "WIN mallory 0 2607":
+			if draws < len(numbers) {
+				t.Fatalf("Bingo after %d draws, with a single number marked", draws)
+			}
+		case msg == "END":
+			if draws < len(numbers) {
+				t.Fatalf("Game over after %d draws", draws)
+			}
+			return
+		default:
+			t.Fatalf("Unexpected message: %s", msg)
+		}
+	}
+}
+
+type result struct {
+	name string
+	win  string
+	err  error
+}
+
+// fakeClient fails the test from the test goroutine only, play reports its errors instead
+type fakeClient struct {
+	t     *testing.T
+	name  string
+	conn  net.Conn
+	lines *bufio.Scanner
+	board *board
+}
+
+func dial(t *testing.T, addr string, name string) *fakeClient {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("%s can't connect: %v", name, err)
+	}
+	c := &fakeClient{t: t, name: name, conn: conn, lines: bufio.NewScanner(conn)}
+	c.send("HELLO %s", name)
+	c.expect("WELCOME " + name)
+	return c
+}
+
+func (c *fakeClient) send(format string, args ...interface{}) {
+	if err := c.trySend(format, args...); err != nil {
+		c.t.Fatalf("%v", err)
+	}
+}
+
+func (c *fakeClient) trySend(format string, args ...interface{}) error {
+	if _, err := fmt.Fprintf(c.conn, format+"\n", args...); err != nil {
+		return fmt.Errorf("%s can't send: %v", c.name, err)
+	}
+	return nil
+}
+
+func (c *fakeClient) receive() (string, error) {
+	_ = c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if !c.lines.Scan() {
+		return "", fmt.Errorf("%s didn't receive a message: %v", c.name, c.lines.Err())
+	}
+	return c.lines.Text(), nil
+}
+
+func (c *fakeClient) expect(want string) {
+	got, err := c.receive()
+	if err != nil {
+		c.t.Fatalf("%v", err)
+	}
+	if got != want {
+		c.t.Fatalf("%s wants [%s], got [%s]", c.name, want, got)
+	}
+}
+
+func (c *fakeClient) claim(k int) {
+	c.send("CLAIM %d", k)
+	msg, err := c.receive()
+	if err != nil {
+		c.t.Fatalf("%v", err)
+	}
+	xs := strings.Fields(msg)
+	if len(xs) < 2 || xs[0] != "BOARD" || xs[1] != strconv.Itoa(k) {
+		c.t.Fatalf("%s wants board %d, got %v", c.name, k, xs)
+	}
+	b, err := parseBoard(xs[2:])
+	if err != nil {
+		c.t.Fatalf("%s got a broken board: %v", c.name, err)
+	}
+	c.board = b
+}
+
+// play marks the board with the draws, calls BINGO on a completed line and returns the WIN message
+func (c *fakeClient) play() (string, error) {
+	var win string
+	for {
+		msg, err := c.receive()
+		if err != nil {
+			return "", err
+		}
+		xs := strings.Fields(msg)
+		switch xs[0] {
+		case "DRAW":
+			if c.board == nil {
+				// spectator
+				continue
+			}
+			n, err := strconv.Atoi(xs[1])
+			if err != nil {
+				return "", fmt.Errorf("%s got a broken draw: %s", c.name, msg)
+			}
+			i, j, ok := c.board.mark(n)
+			if ok && (c.board.markedInRows[i] == 5 || c.board.markedInColumns[j] == 5) {
+				if err := c.trySend("BINGO"); err != nil {
+					return "", err
+				}
+			}
+		case "WIN":
+			win = msg
+		case "END":
+			return win, nil
+		case "ERR":
+			// someone else was faster
+		default:
+			return "", fmt.Errorf("%s got an unexpected message: %s", c.name, msg)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// solveTarget looks for the shortest draw prefix that makes boards[target] win strictly before all the other boards.
+// The prefix is followed by the remaining numbers in their original order and the whole sequence is replayed through
+// play to double-check the result.
+func solveTarget(numbers []int, boards []*board, target int) {
+	if target >= len(boards) {
+		log.Fatalf("There are only %d boards, can't target board %d\n", len(boards), target)
+	}
+
+	prefix, ok := shortestWinningPrefix(numbers, boards, target)
+	if !ok {
+		fmt.Printf("Board %d can't win before the others\n", target)
+		return
+	}
+
+	order := drawOrder(numbers, prefix)
+	replay := make([]*board, 0, len(boards))
+	for _, b := range boards {
+		replay = append(replay, b.fresh())
+	}
+	winner, winningNum := play(order, replay)
+	if winner != target {
+		log.Fatalf("Replay disagrees: board %d won instead of board %d\n", winner, target)
+	}
+
+	fmt.Printf("Prefix: %s\n", formatNumbers(prefix))
+	fmt.Printf("Draws:  %s\n", formatNumbers(order))
+	fmt.Printf("%d\n", replay[winner].sumUnmarked()*winningNum)
+}
+
+// shortestWinningPrefix returns the draws which complete a line of boards[target] without completing any line on
+// another board.
+//
+// A board needs at least 5 marks to win, so 5 draws is the lower bound. It is also enough: drawing the numbers of a
+// single target line marks at most 4 cells in any line of other board, unless that board has exactly the same line.
+// If every target line is shared like this (or can't be drawn from the pool), then no order works, because the
+// sharing board wins on the very same draw as the target.
+func shortestWinningPrefix(numbers []int, boards []*board, target int) ([]int, bool) {
+	pool := make(map[int]bool, len(numbers))
+	for _, n := range numbers {
+		pool[n] = true
+	}
+
+candidates:
+	for _, l := range boards[target].lines() {
+		drawn := make(map[int]bool, 5)
+		for _, n := range l {
+			if !pool[n] {
+				continue candidates
+			}
+			drawn[n] = true
+		}
+		for k, b := range boards {
+			if k == target {
+				continue
+			}
+			if b.completedBy(drawn) {
+				continue candidates
+			}
+		}
+		return l[:], true
+	}
+	return nil, false
+}
+
+// drawOrder puts the prefix first and then the rest of the numbers in their original order.
+func drawOrder(numbers []int, prefix []int) []int {
+	used := make(map[int]bool, len(prefix))
+	order := make([]int, 0, len(numbers))
+	for _, n := range prefix {
+		used[n] = true
+		order = append(order, n)
+	}
+	for _, n := range numbers {
+		if used[n] {
+			// draw every number only once
+			used[n] = false
+			continue
+		}
+		order = append(order, n)
+	}
+	return order
+}
+
+func formatNumbers(numbers []int) string {
+	xs := make([]string, 0, len(numbers))
+	for _, n := range numbers {
+		xs = append(xs, fmt.Sprintf("%d", n))
+	}
+	return strings.Join(xs, ",")
+}
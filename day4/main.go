@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"io"
 	"log"
@@ -11,31 +12,52 @@ import (
 )
 
 func main() {
-	numbers, boards := read(os.Stdin)
+	target := flag.Int("target", -1, "find a draw order that makes the board with this index win first")
+	interactive := flag.Bool("replay", false, "step through the game in the terminal, pass the input file as an argument")
+	flag.Parse()
 
-	var winningBoard *board
-	var winningNum int
-bingo:
+	if *interactive && flag.NArg() == 0 {
+		log.Fatalf("Replay reads the commands from stdin, pass the input file as an argument\n")
+	}
+
+	reader, closer := selectInput()
+	numbers, boards := read(reader)
+	closer()
+
+	if *interactive {
+		replay(numbers, boards, os.Stdin, os.Stdout)
+		return
+	}
+
+	if *target >= 0 {
+		solveTarget(numbers, boards, *target)
+		return
+	}
+
+	winner, winningNum := play(numbers, boards)
+	if winner < 0 {
+		log.Fatalf("No winning board")
+	}
+
+	fmt.Printf("%d\n", boards[winner].sumUnmarked()*winningNum)
+}
+
+// play draws the numbers in order and returns the index of the first board to win together with the winning number.
+// Returns -1 as the index when no board wins.
+func play(numbers []int, boards []*board) (int, int) {
 	for _, n := range numbers {
-		for _, b := range boards {
+		for k, b := range boards {
 			i, j, ok := b.mark(n)
 			if !ok {
 				continue
 			}
 			if b.markedInRows[i] == 5 || b.markedInColumns[j] == 5 {
 				// winning board
-				winningBoard = b
-				winningNum = n
-				break bingo
+				return k, n
 			}
 		}
 	}
-
-	if winningBoard == nil {
-		log.Fatalf("No winning board")
-	}
-
-	fmt.Printf("%d\n", winningBoard.sumUnmarked()*winningNum)
+	return -1, 0
 }
 
 // data model
@@ -46,10 +68,11 @@ type board struct {
 	markedInColumns [5]int
 }
 
+// mark returns false if the number isn't on the board, or if it's already marked, e.g. when drawn twice
 func (b *board) mark(number int) (int, int, bool) {
 	for i := 0; i < 5; i++ {
 		for j := 0; j < 5; j++ {
-			if b.numbers[i][j] == number {
+			if b.numbers[i][j] == number && !b.marked[i][j] {
 				b.marked[i][j] = true
 				b.markedInRows[i]++
 				b.markedInColumns[j]++
@@ -72,6 +95,40 @@ func (b *board) sumUnmarked() int {
 	return sum
 }
 
+// fresh returns an unmarked copy of the board
+func (b *board) fresh() *board {
+	return &board{numbers: b.numbers}
+}
+
+// lines returns the 5 rows followed by the 5 columns
+func (b *board) lines() [10][5]int {
+	var lines [10][5]int
+	for i := 0; i < 5; i++ {
+		for j := 0; j < 5; j++ {
+			lines[i][j] = b.numbers[i][j]
+			lines[5+j][i] = b.numbers[i][j]
+		}
+	}
+	return lines
+}
+
+// completedBy checks if drawing the given numbers would complete any row or column
+func (b *board) completedBy(drawn map[int]bool) bool {
+	for _, l := range b.lines() {
+		complete := true
+		for _, n := range l {
+			if !drawn[n] {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			return true
+		}
+	}
+	return false
+}
+
 // boring input read
 func read(r io.Reader) ([]int, []*board) {
 	numbers := make([]int, 0)
@@ -128,3 +185,18 @@ func read(r io.Reader) ([]int, []*board) {
 
 	return numbers, boards
 }
+
+func selectInput() (reader io.Reader, closer func()) {
+	if flag.NArg() > 0 {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatalf("Can't open %s: %v\n", flag.Arg(0), err)
+		}
+		return f, func() {
+			_ = f.Close()
+		}
+	}
+	return os.Stdin, func() {
+		// do nothing
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	ansiClear  = "\x1b[H\x1b[2J"
+	ansiMarked = "\x1b[1;7m"
+	ansiWon    = "\x1b[1;32m"
+	ansiReset  = "\x1b[0m"
+
+	boardsPerRow = 5
+)
+
+// game keeps playing after the first win, so it covers both part 1 (first winner) and part 2 (last winner)
+type game struct {
+	numbers []int
+	boards  []*board
+	won     []bool
+	scores  []int
+	drawn   int
+	winners []int
+}
+
+func newGame(numbers []int, boards []*board) *game {
+	return &game{
+		numbers: numbers,
+		boards:  boards,
+		won:     make([]bool, len(boards)),
+		scores:  make([]int, len(boards)),
+	}
+}
+
+func (g *game) done() bool {
+	return g.drawn == len(g.numbers) || len(g.winners) == len(g.boards)
+}
+
+// step draws the next number and returns the indexes of the boards that won with it
+func (g *game) step() []int {
+	n := g.numbers[g.drawn]
+	g.drawn++
+
+	var winners []int
+	for k, b := range g.boards {
+		if g.won[k] {
+			continue
+		}
+		i, j, ok := b.mark(n)
+		if !ok {
+			continue
+		}
+		if b.markedInRows[i] == 5 || b.markedInColumns[j] == 5 {
+			g.won[k] = true
+			g.scores[k] = b.sumUnmarked() * n
+			winners = append(winners, k)
+		}
+	}
+	g.winners = append(g.winners, winners...)
+	return winners
+}
+
+// replay steps through the game driven by the commands read from in:
+//
+//	<enter> or n - draw the next number
+//	w            - draw until some board wins
+//	e            - draw until the end of the game
+//	q            - quit
+func replay(numbers []int, boards []*board, in io.Reader, out io.Writer) {
+	g := newGame(numbers, boards)
+	ansi := isTerminal(out)
+	commands := bufio.NewScanner(in)
+
+	render(out, g, ansi)
+	for !g.done() {
+		fmt.Fprintf(out, "[n]ext draw, next [w]in, [e]nd, [q]uit > ")
+		if !commands.Scan() {
+			fmt.Fprintln(out)
+			return
+		}
+		switch strings.TrimSpace(commands.Text()) {
+		case "", "n":
+			g.step()
+		case "w":
+			for !g.done() && len(g.step()) == 0 {
+				// keep drawing
+			}
+		case "e":
+			for !g.done() {
+				g.step()
+			}
+		case "q":
+			return
+		default:
+			fmt.Fprintf(out, "Unknown command %q\n", commands.Text())
+			continue
+		}
+		render(out, g, ansi)
+	}
+}
+
+func render(out io.Writer, g *game, ansi bool) {
+	var buf strings.Builder
+	if ansi {
+		buf.WriteString(ansiClear)
+	}
+
+	if g.drawn > 0 {
+		fmt.Fprintf(&buf, "Draw %d/%d: %d\n", g.drawn, len(g.numbers), g.numbers[g.drawn-1])
+	} else {
+		fmt.Fprintf(&buf, "Draw 0/%d\n", len(g.numbers))
+	}
+	if len(g.winners) > 0 {
+		first, last := g.winners[0], g.winners[len(g.winners)-1]
+		fmt.Fprintf(&buf, "First winner: board %d, score %d\n", first, g.scores[first])
+		fmt.Fprintf(&buf, "Last winner:  board %d, score %d (%d/%d boards won)\n",
+			last, g.scores[last], len(g.winners), len(g.boards))
+	}
+	buf.WriteString("\n")
+
+	for from := 0; from < len(g.boards); from += boardsPerRow {
+		to := from + boardsPerRow
+		if to > len(g.boards) {
+			to = len(g.boards)
+		}
+		renderRow(&buf, g, from, to, ansi)
+	}
+
+	_, _ = io.WriteString(out, buf.String())
+}
+
+// renderRow puts boards[from:to] side by side
+func renderRow(buf *strings.Builder, g *game, from, to int, ansi bool) {
+	for k := from; k < to; k++ {
+		header := fmt.Sprintf("#%d %d", k, g.boards[k].sumUnmarked())
+		if g.won[k] {
+			header = fmt.Sprintf("#%d won %d", k, g.scores[k])
+			if ansi {
+				header = ansiWon + fmt.Sprintf("%-20s", header) + ansiReset
+			}
+		}
+		fmt.Fprintf(buf, "%-20s  ", header)
+	}
+	buf.WriteString("\n")
+
+	for i := 0; i < 5; i++ {
+		for k := from; k < to; k++ {
+			b := g.boards[k]
+			for j := 0; j < 5; j++ {
+				n := b.numbers[i][j]
+				switch {
+				case b.marked[i][j] && ansi:
+					fmt.Fprintf(buf, "%s %2d %s", ansiMarked, n, ansiReset)
+				case b.marked[i][j]:
+					fmt.Fprintf(buf, "[%2d]", n)
+				default:
+					fmt.Fprintf(buf, " %2d ", n)
+				}
+			}
+			buf.WriteString("  ")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+}
+
+// isTerminal checks if out is a character device, which is good enough to decide between ANSI and plain text
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+)
+
+const (
+	// maxDenseCells caps the memory of the dense backend at 128MB, counting the copy of every parallel worker
+	maxDenseCells = 1 << 24
+	// minDenseFill is the smallest ratio of drawn points to cells for which the dense backend pays off
+	minDenseFill = 1.0 / 16
+)
+
+type point struct {
+	x, y int
+}
+
+// bounds is an inclusive rectangle, the coordinates can be negative
+type bounds struct {
+	minX, minY int
+	maxX, maxY int
+}
+
+func (b bounds) width() int {
+	return b.maxX - b.minX + 1
+}
+
+func (b bounds) height() int {
+	return b.maxY - b.minY + 1
+}
+
+// cells is a float, since the area of far apart coordinates doesn't fit in an int
+func (b bounds) cells() float64 {
+	return float64(b.width()) * float64(b.height())
+}
+
+// emptyCells is the number of cells without the crossed ones, exact even past 2^53 and saturating at MaxInt64
+func (b bounds) emptyCells(crossed int) int {
+	// the differences wrap around, but as uint64 they are exact
+	w, h := uint64(b.maxX)-uint64(b.minX)+1, uint64(b.maxY)-uint64(b.minY)+1
+	hi, cells := bits.Mul64(w, h)
+	if hi != 0 || w == 0 || h == 0 {
+		// 2^64 or more
+		return math.MaxInt64
+	}
+	cells -= uint64(crossed)
+	if cells > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int(cells)
+}
+
+// saturatingAdd adds the counts, sticking to MaxInt64 instead of wrapping around
+func saturatingAdd(a, b int) int {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+func (b *bounds) extend(x, y int) {
+	if x < b.minX {
+		b.minX = x
+	}
+	if x > b.maxX {
+		b.maxX = x
+	}
+	if y < b.minY {
+		b.minY = y
+	}
+	if y > b.maxY {
+		b.maxY = y
+	}
+}
+
+// diagram counts how many lines cross every point within the bounds
+type diagram interface {
+	draw(l line)
+	at(x, y int) int
+	count(filter func(int) bool) int
+	// each calls fn for every point crossed by at least one line
+	each(fn func(x, y, n int))
+
+	// empty returns a new diagram with the same backend and bounds
+	empty() diagram
+	// merge adds the counts of the other diagrams, which have to come from empty
+	merge(others []diagram)
+}
+
+// NewDiagram picks the backend based on the range of the coordinates and on how much of it the lines cover.
+// With more than one worker, drawAll keeps a diagram per worker on top of this one.
+func NewDiagram(lines []line, b bounds, r raster, workers int) diagram {
+	var drawn float64
+	for _, l := range lines {
+		drawn += float64(r.length(l))
+	}
+	copies := 1.0
+	if workers > 1 {
+		copies += float64(workers)
+	}
+	if b.cells()*copies <= maxDenseCells && drawn >= b.cells()*minDenseFill {
+		return newDenseDiagram(b, r)
+	}
+	return newSparseDiagram(b, r)
+}
+
+// denseDiagram keeps every cell, board[x-minX][y-minY]
+type denseDiagram struct {
+	bounds
+	raster raster
+	board  [][]int
+}
+
+func newDenseDiagram(b bounds, r raster) *denseDiagram {
+	board := make([][]int, b.width(), b.width())
+	for i := 0; i < b.width(); i++ {
+		board[i] = make([]int, b.height(), b.height())
+	}
+	return &denseDiagram{bounds: b, raster: r, board: board}
+}
+
+func (d *denseDiagram) draw(l line) {
+	d.raster.points(l, func(x, y int) {
+		d.board[x-d.minX][y-d.minY]++
+	})
+}
+
+func (d *denseDiagram) at(x, y int) int {
+	return d.board[x-d.minX][y-d.minY]
+}
+
+func (d *denseDiagram) empty() diagram {
+	return newDenseDiagram(d.bounds, d.raster)
+}
+
+// merge splits the columns between the goroutines, so that they never touch the same cell
+func (d *denseDiagram) merge(others []diagram) {
+	var wg sync.WaitGroup
+	step := (len(d.board) + len(others) - 1) / len(others)
+	for from := 0; from < len(d.board); from += step {
+		to := min(from+step, len(d.board))
+		wg.Add(1)
+		go func(from, to int) {
+			defer wg.Done()
+			for _, other := range others {
+				o := other.(*denseDiagram)
+				for i := from; i < to; i++ {
+					for j := range d.board[i] {
+						d.board[i][j] += o.board[i][j]
+					}
+				}
+			}
+		}(from, to)
+	}
+	wg.Wait()
+}
+
+func (d *denseDiagram) each(fn func(x, y, n int)) {
+	for i := 0; i < len(d.board); i++ {
+		for j := 0; j < len(d.board[0]); j++ {
+			if d.board[i][j] > 0 {
+				fn(i+d.minX, j+d.minY, d.board[i][j])
+			}
+		}
+	}
+}
+
+func (d *denseDiagram) count(filter func(int) bool) int {
+	count := 0
+	for i := 0; i < len(d.board); i++ {
+		for j := 0; j < len(d.board[0]); j++ {
+			if filter(d.board[i][j]) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// sparseDiagram keeps only the points crossed by at least one line
+type sparseDiagram struct {
+	bounds
+	raster raster
+	points map[point]int
+}
+
+func newSparseDiagram(b bounds, r raster) *sparseDiagram {
+	return &sparseDiagram{bounds: b, raster: r, points: map[point]int{}}
+}
+
+func (d *sparseDiagram) draw(l line) {
+	d.raster.points(l, func(x, y int) {
+		d.points[point{x, y}]++
+	})
+}
+
+func (d *sparseDiagram) at(x, y int) int {
+	return d.points[point{x, y}]
+}
+
+func (d *sparseDiagram) empty() diagram {
+	return newSparseDiagram(d.bounds, d.raster)
+}
+
+func (d *sparseDiagram) merge(others []diagram) {
+	for _, other := range others {
+		for p, n := range other.(*sparseDiagram).points {
+			d.points[p] += n
+		}
+	}
+}
+
+func (d *sparseDiagram) each(fn func(x, y, n int)) {
+	for p, n := range d.points {
+		fn(p.x, p.y, n)
+	}
+}
+
+func (d *sparseDiagram) count(filter func(int) bool) int {
+	count := 0
+	for _, n := range d.points {
+		if filter(n) {
+			count++
+		}
+	}
+	if filter(0) {
+		// the points no line crosses
+		count = saturatingAdd(count, d.emptyCells(len(d.points)))
+	}
+	return count
+}
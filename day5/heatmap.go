@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// heat is the colour scale, from the points no line crosses to the most crowded ones
+var heat = []color.RGBA{
+	{0x00, 0x00, 0x00, 0xff},
+	{0x3b, 0x0f, 0x70, 0xff},
+	{0x8c, 0x29, 0x81, 0xff},
+	{0xde, 0x49, 0x68, 0xff},
+	{0xfe, 0x9f, 0x6d, 0xff},
+	{0xfc, 0xfd, 0xbf, 0xff},
+}
+
+var overlayColour = color.RGBA{0x00, 0xff, 0xff, 0xff}
+
+// exportHeatmap writes the diagram as a PNG, every point as a scale x scale square coloured by the number of lines
+// crossing it. With overlay, the lines themselves are drawn on top, through the centres of the squares.
+func exportHeatmap(path string, d diagram, b bounds, lines []line, scale int, overlay bool) error {
+	if b.cells()*float64(scale*scale) > maxDenseCells {
+		return fmt.Errorf("the image of %dx%d points at scale %d is too big", b.width(), b.height(), scale)
+	}
+
+	maxCount := 0
+	for y := b.minY; y <= b.maxY; y++ {
+		for x := b.minX; x <= b.maxX; x++ {
+			if n := d.at(x, y); n > maxCount {
+				maxCount = n
+			}
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, b.width()*scale, b.height()*scale))
+	for y := b.minY; y <= b.maxY; y++ {
+		for x := b.minX; x <= b.maxX; x++ {
+			c := heatColour(d.at(x, y), maxCount)
+			for i := 0; i < scale; i++ {
+				for j := 0; j < scale; j++ {
+					img.SetRGBA((x-b.minX)*scale+i, (y-b.minY)*scale+j, c)
+				}
+			}
+		}
+	}
+
+	if overlay {
+		for _, l := range lines {
+			scaled := line{
+				x1: (l.x1-b.minX)*scale + scale/2, y1: (l.y1-b.minY)*scale + scale/2,
+				x2: (l.x2-b.minX)*scale + scale/2, y2: (l.y2-b.minY)*scale + scale/2,
+			}
+			bresenham.points(scaled, func(x, y int) {
+				img.SetRGBA(x, y, blend(img.RGBAAt(x, y), overlayColour))
+			})
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// heatColour interpolates linearly between the colours of the scale
+func heatColour(n, maxCount int) color.RGBA {
+	if n == 0 || maxCount == 0 {
+		return heat[0]
+	}
+	// 1..maxCount spread over the scale, without the colour reserved for 0
+	t := float64(n-1) / float64(max(maxCount-1, 1)) * float64(len(heat)-2)
+	i := int(t)
+	if i >= len(heat)-2 {
+		return heat[len(heat)-1]
+	}
+	from, to := heat[i+1], heat[i+2]
+	f := t - float64(i)
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*f)
+	}
+	return color.RGBA{mix(from.R, to.R), mix(from.G, to.G), mix(from.B, to.B), 0xff}
+}
+
+// blend mixes the two colours half and half
+func blend(a, b color.RGBA) color.RGBA {
+	return color.RGBA{
+		uint8((uint16(a.R) + uint16(b.R)) / 2),
+		uint8((uint16(a.G) + uint16(b.G)) / 2),
+		uint8((uint16(a.B) + uint16(b.B)) / 2),
+		0xff,
+	}
+}
+
+// partPath adds the suffix before the extension, e.g. vents.png -> vents-part1.png
+func partPath(path string, suffix string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + suffix + ext
+}
@@ -2,31 +2,140 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"runtime"
 )
 
+type config struct {
+	backend string
+	algo    string
+	check   bool
+	raster  raster
+
+	workers int
+
+	hist   bool
+	top    int
+	region *bounds
+
+	png     string
+	scale   int
+	overlay bool
+}
+
+// queries checks if any of the questions beyond the answer is asked
+func (cfg config) queries() bool {
+	return cfg.hist || cfg.top > 0 || cfg.region != nil
+}
+
 func main() {
-	lines, maxX, maxY := read(os.Stdin)
-	diagram := NewDiagram(maxX, maxY)
-	for _, line := range lines {
-		diagram.draw(line)
+	var cfg config
+	flag.StringVar(&cfg.backend, "backend", "auto", "diagram backend: auto, dense or sparse")
+	flag.StringVar(&cfg.algo, "algo", "raster", "how to count the overlaps: raster (draw the diagram) or sweep (from the geometry)")
+	flag.BoolVar(&cfg.check, "check", false, "count the overlaps with both algorithms and compare")
+	mode := flag.String("raster", "lattice", "how to draw lines of any slope: lattice (exact points only) or bresenham")
+	flag.IntVar(&cfg.workers, "workers", 1, "number of goroutines drawing the lines, 0 for one per CPU")
+	flag.BoolVar(&cfg.hist, "hist", false, "print the number of points by the number of lines crossing them")
+	flag.IntVar(&cfg.top, "top", 0, "print this many points crossed by the most lines")
+	region := flag.String("region", "", "count the overlaps only within x1,y1,x2,y2")
+	flag.StringVar(&cfg.png, "png", "", "export the diagram as a PNG heatmap, with -part1/-part2 suffixes when solving both parts")
+	flag.IntVar(&cfg.scale, "scale", 1, "size of a point in the PNG, in pixels")
+	flag.BoolVar(&cfg.overlay, "overlay", false, "draw the input lines over the PNG heatmap")
+	classes := flag.String("classes", "", "lines to draw: any of h(orizontal), v(ertical), d(iagonal), a(ny slope); both parts if empty")
+	flag.Parse()
+
+	switch *mode {
+	case "lattice":
+		cfg.raster = lattice
+	case "bresenham":
+		cfg.raster = bresenham
+	default:
+		log.Fatalf("Unknown raster mode: %s\n", *mode)
+	}
+	if *region != "" {
+		r := parseRegion(*region)
+		cfg.region = &r
+	}
+	if cfg.workers <= 0 {
+		cfg.workers = runtime.NumCPU()
+	}
+	if cfg.scale < 1 {
+		log.Fatalf("Scale has to be at least 1, got %d\n", cfg.scale)
+	}
+	if cfg.algo != "raster" && cfg.algo != "sweep" {
+		log.Fatalf("Unknown algorithm: %s\n", cfg.algo)
 	}
 
-	if maxX < 20 && maxY < 20 {
-		printDiagram(diagram)
+	lines, b := read(os.Stdin)
+
+	if *classes == "" {
+		part1, part2 := cfg, cfg
+		if cfg.png != "" {
+			part1.png, part2.png = partPath(cfg.png, "part1"), partPath(cfg.png, "part2")
+		}
+		fmt.Printf("Part 1: %d\n", solve(part1, admit(lines, horizontal|vertical), b))
+		fmt.Printf("Part 2: %d\n", solve(part2, admit(lines, horizontal|vertical|diagonal), b))
+		return
 	}
-	fmt.Printf("\nAnswer: %d\n", diagram.count(func(n int) bool {
+	fmt.Printf("Answer: %d\n", solve(cfg, admit(lines, parseClasses(*classes)), b))
+}
+
+// solve counts the points where at least two of the lines overlap
+func solve(cfg config, lines []line, b bounds) int {
+	if cfg.algo == "sweep" && !cfg.check && cfg.png == "" && !cfg.queries() {
+		return mustSweep(lines)
+	}
+
+	var diagram diagram
+	switch cfg.backend {
+	case "auto":
+		diagram = NewDiagram(lines, b, cfg.raster, cfg.workers)
+	case "dense":
+		diagram = newDenseDiagram(b, cfg.raster)
+	case "sparse":
+		diagram = newSparseDiagram(b, cfg.raster)
+	default:
+		log.Fatalf("Unknown backend: %s\n", cfg.backend)
+	}
+	drawAll(diagram, lines, cfg.workers)
+
+	if b.width() < 20 && b.height() < 20 {
+		printDiagram(diagram, b)
+		fmt.Println()
+	}
+	if cfg.png != "" {
+		if err := exportHeatmap(cfg.png, diagram, b, lines, cfg.scale, cfg.overlay); err != nil {
+			log.Fatalf("Can't export the heatmap to %s: %v\n", cfg.png, err)
+		}
+	}
+	printQueries(cfg, diagram, b)
+	answer := diagram.count(func(n int) bool {
 		return n >= 2
-	}))
+	})
+	if cfg.check {
+		if n := mustSweep(lines); n != answer {
+			log.Fatalf("Raster counts %d overlaps, sweep counts %d\n", answer, n)
+		}
+	}
+	return answer
+}
+
+func mustSweep(lines []line) int {
+	n, ok := sweepCount(lines)
+	if !ok {
+		log.Fatalf("Sweep supports only horizontal, vertical and diagonal lines\n")
+	}
+	return n
 }
 
-func printDiagram(diagram *diagram) {
-	for i := 0; i < len(diagram.board); i++ {
-		for j := 0; j < len(diagram.board[0]); j++ {
-			pixel := diagram.board[j][i]
+func printDiagram(diagram diagram, b bounds) {
+	for y := b.minY; y <= b.maxY; y++ {
+		for x := b.minX; x <= b.maxX; x++ {
+			pixel := diagram.at(x, y)
 			if pixel == 0 {
 				fmt.Printf(".")
 			} else {
@@ -43,53 +152,159 @@ type line struct {
 	x2, y2 int
 }
 
-type diagram struct {
-	board [][]int
-}
+// class of the line, by the direction
+type class uint8
+
+const (
+	horizontal class = 1 << iota
+	vertical
+	rising  // diagonal, up and right
+	falling // diagonal, down and right
+	arbitrary
+
+	diagonal = rising | falling
+)
 
-func NewDiagram(maxX, maxY int) *diagram {
-	board := make([][]int, maxX+1, maxX+1)
-	for i := 0; i < maxX+1; i++ {
-		board[i] = make([]int, maxY+1, maxY+1)
+func (l line) class() class {
+	switch {
+	case l.y1 == l.y2:
+		return horizontal
+	case l.x1 == l.x2:
+		return vertical
+	case l.y2-l.y1 == l.x2-l.x1:
+		return rising
+	case l.y2-l.y1 == l.x1-l.x2:
+		return falling
+	default:
+		return arbitrary
 	}
-	return &diagram{board: board}
 }
 
-func (d *diagram) draw(l line) {
-	if l.x1 == l.x2 {
-		from, to := l.y1, l.y2
-		if l.y1 > l.y2 {
-			from, to = l.y2, l.y1
+func parseClasses(in string) class {
+	var classes class
+	for _, c := range in {
+		switch c {
+		case 'h':
+			classes |= horizontal
+		case 'v':
+			classes |= vertical
+		case 'd':
+			classes |= diagonal
+		case 'a':
+			classes |= arbitrary
+		default:
+			log.Fatalf("Unknown line class: %c\n", c)
 		}
-		for j := from; j <= to; j++ {
-			d.board[l.x1][j]++
+	}
+	return classes
+}
+
+// admit keeps only the lines of the given classes
+func admit(lines []line, classes class) []line {
+	result := make([]line, 0, len(lines))
+	for _, l := range lines {
+		if l.class()&classes != 0 {
+			result = append(result, l)
 		}
 	}
-	if l.y1 == l.y2 {
-		from, to := l.x1, l.x2
-		if l.x1 > l.x2 {
-			from, to = l.x2, l.x1
+	return result
+}
+
+// validate rejects the lines which can't be drawn
+func (l line) validate() error {
+	if l.x1 == l.x2 && l.y1 == l.y2 {
+		return fmt.Errorf("degenerate line %d,%d -> %d,%d has no direction", l.x1, l.y1, l.x2, l.y2)
+	}
+	return nil
+}
+
+// vector is the smallest step between two points of the line on the integer grid,
+// e.g. the line 0,0 -> 6,4 has the step (3,2) and the length of 3 points
+func (l line) vector() (dx int, dy int, length int) {
+	dx = l.x2 - l.x1
+	dy = l.y2 - l.y1
+	g := gcd(abs(dx), abs(dy))
+	return dx / g, dy / g, g + 1
+}
+
+type raster int
+
+const (
+	// lattice draws only the points exactly on the line
+	lattice raster = iota
+	// bresenham draws the point closest to the line in every column (or row, for the steep lines)
+	bresenham
+)
+
+// length is the number of points drawn for the line
+func (r raster) length(l line) int {
+	if r == bresenham {
+		return max(abs(l.x2-l.x1), abs(l.y2-l.y1)) + 1
+	}
+	_, _, length := l.vector()
+	return length
+}
+
+// points calls fn for every point of the line
+func (r raster) points(l line, fn func(x, y int)) {
+	if r == bresenham {
+		bresenhamPoints(l, fn)
+		return
+	}
+	dx, dy, length := l.vector()
+	x := l.x1
+	y := l.y1
+	for n := 0; n < length; n++ {
+		fn(x, y)
+		x += dx
+		y += dy
+	}
+}
+
+func bresenhamPoints(l line, fn func(x, y int)) {
+	dx, dy := abs(l.x2-l.x1), -abs(l.y2-l.y1)
+	sx, sy := 1, 1
+	if l.x1 > l.x2 {
+		sx = -1
+	}
+	if l.y1 > l.y2 {
+		sy = -1
+	}
+	x, y := l.x1, l.y1
+	e := dx + dy
+	for {
+		fn(x, y)
+		if x == l.x2 && y == l.y2 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x += sx
 		}
-		for i := from; i <= to; i++ {
-			d.board[i][l.y1]++
+		if e2 <= dx {
+			e += dx
+			y += sy
 		}
 	}
 }
 
-func (d *diagram) count(filter func(int) bool) int {
-	count := 0
-	for i := 0; i < len(d.board); i++ {
-		for j := 0; j < len(d.board[0]); j++ {
-			if filter(d.board[i][j]) {
-				count++
-			}
-		}
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
 	}
-	return count
+	return a
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
 }
 
 // boring input read
-func read(r io.Reader) (lines []line, maxX int, maxY int) {
+func read(r io.Reader) (lines []line, b bounds) {
 	lines = make([]line, 0)
 
 	s := bufio.NewScanner(r)
@@ -99,19 +314,16 @@ func read(r io.Reader) (lines []line, maxX int, maxY int) {
 		if _, err := fmt.Sscanf(s.Text(), "%d,%d -> %d,%d", &x1, &y1, &x2, &y2); err != nil {
 			log.Fatalf("Can't parse line of input: %v", err)
 		}
-		lines = append(lines, line{x1: x1, y1: y1, x2: x2, y2: y2})
-		if x1 > maxX {
-			maxX = x1
-		}
-		if x2 > maxX {
-			maxX = x2
-		}
-		if y1 > maxY {
-			maxY = y1
+		l := line{x1: x1, y1: y1, x2: x2, y2: y2}
+		if err := l.validate(); err != nil {
+			log.Fatalf("Invalid line %d of input: %v", len(lines)+1, err)
 		}
-		if y2 > maxY {
-			maxY = y2
+		if len(lines) == 0 {
+			b = bounds{minX: x1, minY: y1, maxX: x1, maxY: y1}
 		}
+		lines = append(lines, l)
+		b.extend(x1, y1)
+		b.extend(x2, y2)
 	}
 
 	if err := s.Err(); err != nil {
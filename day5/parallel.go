@@ -0,0 +1,34 @@
+package main
+
+import (
+	"sync"
+)
+
+// drawAll draws the lines one by one, or splits them between the workers. Every worker draws into its own
+// diagram and the diagrams are summed up at the end, so the result doesn't depend on the scheduling. The copies
+// take memory, NewDiagram counts them in when it picks the backend.
+func drawAll(d diagram, lines []line, workers int) {
+	if workers <= 1 {
+		for _, line := range lines {
+			d.draw(line)
+		}
+		return
+	}
+
+	parts := make([]diagram, workers)
+	step := (len(lines) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		parts[w] = d.empty()
+		from, to := min(w*step, len(lines)), min((w+1)*step, len(lines))
+		wg.Add(1)
+		go func(part diagram, lines []line) {
+			defer wg.Done()
+			for _, line := range lines {
+				part.draw(line)
+			}
+		}(parts[w], lines[from:to])
+	}
+	wg.Wait()
+	d.merge(parts)
+}
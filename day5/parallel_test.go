@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"testing"
+)
+
+func readFile(tb testing.TB, path string) ([]line, bounds) {
+	tb.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		tb.Fatalf("Can't open %s: %v", path, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return read(f)
+}
+
+func TestDrawParallel(t *testing.T) {
+	lines, b := readFile(t, "input.txt")
+	lines = admit(lines, horizontal|vertical|diagonal)
+	overlaps := func(n int) bool {
+		return n >= 2
+	}
+	for _, workers := range []int{1, 2, 3, 8} {
+		d := NewDiagram(lines, b, lattice, workers)
+		drawAll(d, lines, workers)
+		if got := d.count(overlaps); got != 23864 {
+			t.Errorf("%d workers count %d overlaps, want 23864", workers, got)
+		}
+	}
+}
+
+func benchmarkDraw(b *testing.B, workers int) {
+	lines, bs := readFile(b, "input.txt")
+	lines = admit(lines, horizontal|vertical|diagonal)
+	d := NewDiagram(lines, bs, lattice, workers)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		fresh := d.empty()
+		b.StartTimer()
+		drawAll(fresh, lines, workers)
+		d = fresh
+	}
+	b.StopTimer()
+	if got := d.count(func(n int) bool { return n >= 2 }); got != 23864 {
+		b.Fatalf("%d workers count %d overlaps, want 23864", workers, got)
+	}
+}
+
+func BenchmarkDrawSequential(b *testing.B) {
+	benchmarkDraw(b, 1)
+}
+
+func BenchmarkDrawParallel(b *testing.B) {
+	benchmarkDraw(b, runtime.NumCPU())
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+)
+
+type hotspot struct {
+	point
+	n int
+}
+
+// histogram counts the points by the number of lines crossing them, including the points no line crosses,
+// up to MaxInt64
+func histogram(d diagram, b bounds) map[int]int {
+	hist := map[int]int{}
+	crossed := 0
+	d.each(func(x, y, n int) {
+		hist[n]++
+		crossed++
+	})
+	hist[0] = b.emptyCells(crossed)
+	return hist
+}
+
+// top returns the k points crossed by the most lines, the ties ordered top to bottom and left to right
+func top(d diagram, k int) []hotspot {
+	spots := make([]hotspot, 0)
+	d.each(func(x, y, n int) {
+		spots = append(spots, hotspot{point{x, y}, n})
+	})
+	sort.Slice(spots, func(i, j int) bool {
+		if spots[i].n != spots[j].n {
+			return spots[i].n > spots[j].n
+		}
+		if spots[i].y != spots[j].y {
+			return spots[i].y < spots[j].y
+		}
+		return spots[i].x < spots[j].x
+	})
+	if len(spots) > k {
+		spots = spots[:k]
+	}
+	return spots
+}
+
+// countIn is count limited to a region, which doesn't have to be within the bounds of the diagram
+func countIn(d diagram, region bounds, filter func(int) bool) int {
+	count := 0
+	crossed := 0
+	d.each(func(x, y, n int) {
+		if x < region.minX || x > region.maxX || y < region.minY || y > region.maxY {
+			return
+		}
+		crossed++
+		if filter(n) {
+			count++
+		}
+	})
+	if filter(0) {
+		count = saturatingAdd(count, region.emptyCells(crossed))
+	}
+	return count
+}
+
+func parseRegion(in string) bounds {
+	var x1, y1, x2, y2 int
+	if _, err := fmt.Sscanf(in, "%d,%d,%d,%d", &x1, &y1, &x2, &y2); err != nil {
+		log.Fatalf("Can't parse region %s, want x1,y1,x2,y2: %v\n", in, err)
+	}
+	r := bounds{minX: x1, minY: y1, maxX: x1, maxY: y1}
+	r.extend(x2, y2)
+	return r
+}
+
+func printQueries(cfg config, d diagram, b bounds) {
+	if cfg.hist {
+		hist := histogram(d, b)
+		counts := make([]int, 0, len(hist))
+		for n := range hist {
+			counts = append(counts, n)
+		}
+		sort.Ints(counts)
+		fmt.Printf("Histogram:\n")
+		for _, n := range counts {
+			fmt.Printf("  %3d lines: %d points\n", n, hist[n])
+		}
+	}
+	if cfg.top > 0 {
+		fmt.Printf("Top %d:\n", cfg.top)
+		for _, spot := range top(d, cfg.top) {
+			fmt.Printf("  %d,%d: %d lines\n", spot.x, spot.y, spot.n)
+		}
+	}
+	if cfg.region != nil {
+		fmt.Printf("Overlaps in %d,%d -> %d,%d: %d\n",
+			cfg.region.minX, cfg.region.minY, cfg.region.maxX, cfg.region.maxY,
+			countIn(d, *cfg.region, func(n int) bool {
+				return n >= 2
+			}))
+	}
+}
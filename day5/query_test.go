@@ -0,0 +1,26 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEmptyCells(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		b       bounds
+		crossed int
+		want    int
+	}{
+		{"single cell", bounds{0, 0, 0, 0}, 1, 0},
+		{"negative coordinates", bounds{-2, -3, 2, 3}, 5, 5*7 - 5},
+		// 2^53 + 1 isn't a float64
+		{"past 2^53", bounds{0, 0, 1 << 53, 0}, 0, 1<<53 + 1},
+		{"past MaxInt64", bounds{0, 0, 4000000001, 4000000000}, 4, math.MaxInt64},
+		{"the whole plane", bounds{math.MinInt64, math.MinInt64, math.MaxInt64, math.MaxInt64}, 0, math.MaxInt64},
+	} {
+		if got := tc.b.emptyCells(tc.crossed); got != tc.want {
+			t.Errorf("%s: %d empty cells, want %d", tc.desc, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// Every horizontal, vertical or diagonal line lies on a carrier: an infinite line a*x + b*y = c.
+// The parameter along the carrier is y for the vertical lines and x for the others.
+func (cl class) coefficients() (a, b int) {
+	switch cl {
+	case horizontal:
+		return 0, 1
+	case vertical:
+		return 1, 0
+	case rising:
+		return -1, 1
+	default:
+		return 1, 1
+	}
+}
+
+// piece is a part of a carrier covered by the same number of lines
+type piece struct {
+	class    class
+	c        int
+	from, to int
+	multi    bool // covered by at least two lines
+}
+
+func (p piece) xRange() (int, int) {
+	if p.class == vertical {
+		return p.c, p.c
+	}
+	return p.from, p.to
+}
+
+// cross returns the point where the two pieces meet, if it is on the integer grid
+func (p piece) cross(o piece) (point, bool) {
+	a1, b1 := p.class.coefficients()
+	a2, b2 := o.class.coefficients()
+	det := a1*b2 - a2*b1
+	if det == 0 {
+		return point{}, false
+	}
+	nx := p.c*b2 - o.c*b1
+	ny := a1*o.c - a2*p.c
+	if nx%det != 0 || ny%det != 0 {
+		return point{}, false
+	}
+	at := point{x: nx / det, y: ny / det}
+	return at, p.contains(at) && o.contains(at)
+}
+
+// contains assumes that the point is on the carrier
+func (p piece) contains(at point) bool {
+	param := at.x
+	if p.class == vertical {
+		param = at.y
+	}
+	return p.from <= param && param <= p.to
+}
+
+// carrier returns the constant of the carrier and the range of the parameter along it
+func (l line) carrier() (c int, from int, to int) {
+	switch l.class() {
+	case horizontal:
+		return l.y1, min(l.x1, l.x2), max(l.x1, l.x2)
+	case vertical:
+		return l.x1, min(l.y1, l.y2), max(l.y1, l.y2)
+	case rising:
+		return l.y1 - l.x1, min(l.x1, l.x2), max(l.x1, l.x2)
+	default:
+		return l.y1 + l.x1, min(l.x1, l.x2), max(l.x1, l.x2)
+	}
+}
+
+// sweepCount counts the points crossed by at least two lines straight from the geometry, without a diagram.
+// It only supports horizontal, vertical and diagonal lines, returns false if any other line is given.
+//
+// First, the lines on the same carrier are swept along the carrier to find the pieces covered by two or more of
+// them. Then the pieces are swept along the x-axis to find the points where the carriers cross. A point counts if
+// it is on a multi-covered piece, or on the pieces of at least two carriers.
+func sweepCount(lines []line) (int, bool) {
+	type carrier struct {
+		class class
+		c     int
+	}
+	type event struct {
+		at    int
+		delta int
+	}
+
+	// collinear overlaps
+	events := map[carrier][]event{}
+	for _, l := range lines {
+		if l.class() == arbitrary {
+			return 0, false
+		}
+		c, from, to := l.carrier()
+		k := carrier{l.class(), c}
+		events[k] = append(events[k], event{from, +1}, event{to + 1, -1})
+	}
+
+	count := 0
+	pieces := make([]piece, 0)
+	for k, es := range events {
+		sort.Slice(es, func(i, j int) bool {
+			return es[i].at < es[j].at
+		})
+		covered := 0
+		for i, e := range es {
+			covered += e.delta
+			if i+1 == len(es) || es[i+1].at == e.at || covered == 0 {
+				continue
+			}
+			p := piece{class: k.class, c: k.c, from: e.at, to: es[i+1].at - 1, multi: covered >= 2}
+			if p.multi {
+				count += p.to - p.from + 1
+			}
+			pieces = append(pieces, p)
+		}
+	}
+
+	// crossings, remembering which classes (and which of them multi-covered) meet at every point
+	type crossing struct {
+		classes class
+		multi   class
+	}
+	crossings := map[point]*crossing{}
+	record := func(at point, p piece) {
+		cr, ok := crossings[at]
+		if !ok {
+			cr = &crossing{}
+			crossings[at] = cr
+		}
+		cr.classes |= p.class
+		if p.multi {
+			cr.multi |= p.class
+		}
+	}
+
+	sort.Slice(pieces, func(i, j int) bool {
+		fi, _ := pieces[i].xRange()
+		fj, _ := pieces[j].xRange()
+		return fi < fj
+	})
+	active := make([]piece, 0)
+	for _, p := range pieces {
+		from, _ := p.xRange()
+		stillActive := active[:0]
+		for _, o := range active {
+			if _, to := o.xRange(); to >= from {
+				stillActive = append(stillActive, o)
+			}
+		}
+		active = stillActive
+
+		for _, o := range active {
+			if at, ok := p.cross(o); ok {
+				record(at, p)
+				record(at, o)
+			}
+		}
+		active = append(active, p)
+	}
+
+	// each multi-covered class at a crossing has already been counted once, but the point counts only once
+	for _, cr := range crossings {
+		if m := bits.OnesCount8(uint8(cr.multi)); m == 0 {
+			count++
+		} else {
+			count -= m - 1
+		}
+	}
+	return count, true
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
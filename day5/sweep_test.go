@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+)
+
+// rasterCount counts the overlaps by drawing the lines on a dense diagram
+func rasterCount(lines []line) int {
+	b := bounds{minX: lines[0].x1, minY: lines[0].y1, maxX: lines[0].x1, maxY: lines[0].y1}
+	for _, l := range lines {
+		b.extend(l.x1, l.y1)
+		b.extend(l.x2, l.y2)
+	}
+	d := newDenseDiagram(b, lattice)
+	for _, l := range lines {
+		d.draw(l)
+	}
+	return d.count(func(n int) bool {
+		return n >= 2
+	})
+}
+
+func TestSweepMatchesRaster(t *testing.T) {
+	for _, tc := range []struct {
+		desc  string
+		lines []line
+	}{
+		{"horizontal partial overlaps", []line{
+			{0, 0, 5, 0}, {3, 0, 8, 0}, {10, 0, 8, 0}, {12, 0, 20, 0}, {14, 0, 15, 0},
+		}},
+		{"vertical partial overlaps", []line{
+			{2, 0, 2, 6}, {2, 4, 2, 9}, {2, 9, 2, 5}, {2, -3, 2, -1},
+		}},
+		{"rising partial overlaps", []line{
+			{0, 0, 5, 5}, {3, 3, 8, 8}, {9, 9, 7, 7}, {-4, -2, -1, 1}, {-3, -1, -2, 0},
+		}},
+		{"falling partial overlaps", []line{
+			{0, 8, 8, 0}, {4, 4, 10, -2}, {10, -2, 9, -1}, {0, 3, 3, 0},
+		}},
+		{"crossing off the lattice", []line{
+			// x - y = 0 and x + y = 5 cross at 2.5,2.5
+			{0, 0, 5, 5}, {0, 5, 5, 0},
+		}},
+		{"crossing on the lattice", []line{
+			{0, 0, 6, 6}, {0, 6, 6, 0}, {3, -1, 3, 8}, {-1, 3, 7, 3},
+		}},
+		{"crossing of multi-covered carriers", []line{
+			{0, 3, 6, 3}, {1, 3, 5, 3},
+			{3, 0, 3, 6}, {3, 2, 3, 8},
+			// and a single diagonal through the crossing and through both multi-covered pieces
+			{0, 0, 6, 6},
+		}},
+		{"multi-covered diagonals", []line{
+			{0, 0, 6, 6}, {2, 2, 8, 8},
+			{0, 8, 8, 0}, {1, 7, 5, 3},
+		}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, ok := sweepCount(tc.lines)
+			if !ok {
+				t.Fatalf("Sweep refuses the lines")
+			}
+			if want := rasterCount(tc.lines); got != want {
+				t.Errorf("Sweep counts %d overlaps, the raster %d", got, want)
+			}
+		})
+	}
+}
+
+func TestSweepInputs(t *testing.T) {
+	for _, tc := range []struct {
+		path         string
+		part1, part2 int
+	}{
+		{"example.txt", 5, 12},
+		{"input.txt", 6005, 23864},
+	} {
+		all, _ := readFile(t, tc.path)
+		for classes, want := range map[class]int{
+			horizontal | vertical:            tc.part1,
+			horizontal | vertical | diagonal: tc.part2,
+		} {
+			lines := admit(all, classes)
+			got, ok := sweepCount(lines)
+			if !ok {
+				t.Fatalf("%s: sweep refuses the lines", tc.path)
+			}
+			if raster := rasterCount(lines); got != want || raster != want {
+				t.Errorf("%s: sweep counts %d overlaps, the raster %d, want %d", tc.path, got, raster, want)
+			}
+		}
+	}
+}
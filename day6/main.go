@@ -2,84 +2,268 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"io"
 	"log"
+	"math"
+	"math/big"
+	"math/bits"
 	"os"
 	"strconv"
 	"strings"
 )
 
 func main() {
-	school := read()
-	var (
-		acc      = make(map[cacheKey]uint64)
-		sumPart1 uint64
-		sumPart2 uint64
-	)
-	for _, f := range school {
-		sumPart1 += grow(acc, f, 80)
-		sumPart2 += grow(acc, f, 256)
-	}
-	fmt.Printf("Answer part 1: %d\n", sumPart1)
-	fmt.Printf("Answer part 2: %d\n", sumPart2)
+	engine := flag.String("engine", "memo", "how to count the fish: memo (grow every fish) or matrix (fast-forward the whole school)")
+	days := flag.Int("days", 0, "print the population after this many days instead of parts 1 and 2")
+	check := flag.Bool("check", false, "cross-check the matrix engine against grow for parts 1 and 2")
+	sp := lanternfish
+	flag.IntVar(&sp.reset, "reset", sp.reset, "timer after spawning")
+	flag.IntVar(&sp.newborn, "newborn", sp.newborn, "timer of a freshly spawned fish")
+	flag.IntVar(&sp.deathAge, "death", sp.deathAge, "the fish dies after living this many days, 0 for immortal")
+	flag.IntVar(&sp.spawnCount, "spawn", sp.spawnCount, "fish spawned at once")
+	series := flag.String("series", "", "print the population and the timers for every day up to the horizon: csv or json")
+	horizon := flag.Int("horizon", 256, "last day of the series and the sparkline")
+	spark := flag.Bool("sparkline", false, "print a sparkline of the population up to the horizon, to stderr with -series")
+	reach := flag.String("reach", "", "print the first day the population reaches this many fish")
+	speciesFile := flag.String("species", "", "file with a species per line, the input then has a line of fish per species")
+	flag.Parse()
+
+	allSpecies := []species{sp}
+	if *speciesFile != "" {
+		allSpecies = mustReadSpecies(*speciesFile)
+	} else if err := sp.validate(); err != nil {
+		log.Fatalf("Invalid species: %v\n", err)
+	}
+	school := read(allSpecies)
+
+	if *series != "" || *spark {
+		printSeries(school, *series, *horizon, *spark)
+		return
+	}
+
+	if *reach != "" {
+		threshold, ok := new(big.Int).SetString(*reach, 10)
+		if !ok {
+			log.Fatalf("Can't parse %s as a number\n", *reach)
+		}
+		day, ok := firstDayReaching(school, threshold)
+		if !ok {
+			log.Fatalf("The population never reaches %s\n", threshold)
+		}
+		fmt.Printf("Day %d: %s fish\n", day, populationAt(school, day))
+		return
+	}
+
+	var population func(days int) *big.Int
+	switch *engine {
+	case "memo":
+		population = func(days int) *big.Int {
+			n, ok := memoPopulation(school, days)
+			if !ok {
+				// past uint64, promote to the exact matrix engine
+				return populationAt(school, days)
+			}
+			return new(big.Int).SetUint64(n)
+		}
+	case "matrix":
+		population = func(days int) *big.Int {
+			return populationAt(school, days)
+		}
+	default:
+		log.Fatalf("Unknown engine: %s\n", *engine)
+	}
+
+	// -days replaces parts 1 and 2, a fast-growing species may not even fit in uint64 by day 80
+	type question struct {
+		desc string
+		days int
+	}
+	asked := []question{{"part 1", 80}, {"part 2", 256}}
+	if *days > 0 {
+		asked = []question{{fmt.Sprintf("day %d", *days), *days}}
+	}
+
+	for _, a := range asked {
+		if *check {
+			memo, ok := memoPopulation(school, a.days)
+			fast := populationAt(school, a.days)
+			switch {
+			case !ok:
+				log.Printf("Day %d: grow overflows uint64 (the matrix counts %s fish), nothing to compare\n", a.days, fast)
+			case !fast.IsUint64() || fast.Uint64() != memo:
+				log.Fatalf("Day %d: grow counts %d fish, the matrix %s\n", a.days, memo, fast)
+			}
+		}
+		fmt.Printf("Answer %s: %d\n", a.desc, population(a.days))
+	}
 }
 
-const timerReset = 6
-const timerNew = 8
+func printSeries(school []shoal, format string, horizon int, spark bool) {
+	if horizon < 0 {
+		log.Fatalf("Horizon can't be negative, got %d\n", horizon)
+	}
+	series := timeSeries(school, horizon)
+
+	var err error
+	switch format {
+	case "csv":
+		err = writeCSV(os.Stdout, series)
+	case "json":
+		err = writeJSON(os.Stdout, series)
+	case "":
+	default:
+		log.Fatalf("Unknown series format: %s\n", format)
+	}
+	if err != nil {
+		log.Fatalf("Can't write the series: %v\n", err)
+	}
+
+	if spark {
+		// the growth rate over a full cycle of the slowest species
+		window := 0
+		for _, sh := range school {
+			if sh.species.reset+1 > window {
+				window = sh.species.reset + 1
+			}
+		}
+		out := os.Stdout
+		if format != "" {
+			out = os.Stderr
+		}
+		fmt.Fprintln(out, sparkline(series, window))
+	}
+}
+
+// memoPopulation returns false if the population doesn't fit in uint64
+func memoPopulation(school []shoal, days int) (uint64, bool) {
+	var sum uint64
+	for _, sh := range school {
+		acc := make(map[cacheKey]uint64)
+		for _, f := range sh.fish {
+			sum = saturatingAdd(sum, grow(acc, sh.species, f, 0, days))
+		}
+	}
+	return sum, sum != math.MaxUint64
+}
+
+// populationAt returns the exact population at the end of the given day, fast-forwarding every species
+func populationAt(school []shoal, days int) *big.Int {
+	sum := new(big.Int)
+	for _, sh := range school {
+		sum.Add(sum, fastForward(sh, days).total())
+	}
+	return sum
+}
+
+// saturatingAdd sticks to math.MaxUint64 instead of wrapping around
+func saturatingAdd(a, b uint64) uint64 {
+	sum, carry := bits.Add64(a, b, 0)
+	if carry != 0 {
+		return math.MaxUint64
+	}
+	return sum
+}
+
+func saturatingMul(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	if hi != 0 {
+		return math.MaxUint64
+	}
+	return lo
+}
 
 type cacheKey struct {
 	timer int
+	age   int
 	days  int
 }
 
-func grow(acc map[cacheKey]uint64, timer int, days int) (result uint64) {
-	if n, ok := acc[cacheKey{timer, days}]; ok {
+// grow counts the fish after the given number of days, starting from a single fish.
+// The age matters only for the mortal species, for the others it's always 0.
+func grow(acc map[cacheKey]uint64, sp species, timer int, age int, days int) (result uint64) {
+	if n, ok := acc[cacheKey{timer, age, days}]; ok {
 		return n
 	}
-	if timer >= days { // no time for any more spawns
+	nextAge, alive := age, true
+	if sp.mortal() {
+		nextAge = age + 1
+		alive = nextAge < sp.deathAge
+	}
+	if days == 0 || timer >= days && (!sp.mortal() || age+days < sp.deathAge) { // no time for any more spawns
 		result = 1
 	} else if timer > 0 { // yet another day
-		result = grow(acc, timer-1, days-1)
+		if alive {
+			result = grow(acc, sp, timer-1, nextAge, days-1)
+		}
 	} else { // neat, new fish
-		result = grow(acc, timerReset, days-1) + grow(acc, timerNew, days-1)
+		if alive {
+			result = grow(acc, sp, sp.reset, nextAge, days-1)
+		}
+		spawn := saturatingMul(uint64(sp.spawnCount), grow(acc, sp, sp.newborn, 0, days-1))
+		result = saturatingAdd(result, spawn)
 	}
-	acc[cacheKey{timer, days}] = result
+	acc[cacheKey{timer, age, days}] = result
 	return
 }
 
-// boring input read
-func read() []int {
-	var school []int
+func mustReadSpecies(path string) []species {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Can't open %s: %v\n", path, err)
+	}
+	defer f.Close()
+	result, err := readSpecies(f)
+	if err != nil {
+		log.Fatalf("Can't read the species from %s: %v\n", path, err)
+	}
+	return result
+}
 
+// boring input read, a line of fish per species
+func read(allSpecies []species) []shoal {
 	reader, closer := selectInput()
 	defer closer()
+	return readSchool(reader, allSpecies)
+}
+
+func readSchool(reader io.Reader, allSpecies []species) []shoal {
+	school := make([]shoal, 0, len(allSpecies))
 	s := bufio.NewScanner(reader)
 
-	if s.Scan() {
+	for s.Scan() && len(school) < len(allSpecies) {
+		sp := allSpecies[len(school)]
 		xs := strings.Split(s.Text(), ",")
-		school = make([]int, len(xs), len(xs))
+		fish := make([]int, len(xs), len(xs))
 		for i, x := range xs {
 			f, err := strconv.Atoi(x)
 			if err != nil {
-				log.Fatalf("Can't parse fish[%d] = %s as a number: %v", i, x, err)
+				log.Fatalf("Can't parse %s[%d] = %s as a number: %v", sp.name, i, x, err)
+			}
+			if f < 0 || f >= sp.timers() {
+				log.Fatalf("The timer of %s[%d] = %d is out of range [0, %d]", sp.name, i, f, sp.timers()-1)
 			}
-			school[i] = f
+			fish[i] = f
 		}
+		school = append(school, shoal{species: sp, fish: fish})
 	}
 
 	if err := s.Err(); err != nil {
 		log.Fatalf("Scanner errors: %v\n", err)
 	}
+	if len(school) != len(allSpecies) {
+		log.Fatalf("Want a line of fish for each of the %d species, got %d\n", len(allSpecies), len(school))
+	}
 
 	return school
 }
 
 func selectInput() (reader io.Reader, closer func()) {
-	if len(os.Args) > 1 {
-		f, err := os.Open(os.Args[1])
+	if flag.NArg() > 0 {
+		f, err := os.Open(flag.Arg(0))
 		if err != nil {
-			log.Fatalf("Can't open %s: %v\n", os.Args[1], err)
+			log.Fatalf("Can't open %s: %v\n", flag.Arg(0), err)
 		}
 		return f, func() {
 			_ = f.Close()
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math/big"
+	"os"
+	"testing"
+)
+
+func readFile(t *testing.T, path string) []shoal {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Can't open %s: %v", path, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return readSchool(f, []species{lanternfish})
+}
+
+func TestEngines(t *testing.T) {
+	for _, tc := range []struct {
+		path         string
+		part1, part2 uint64
+	}{
+		{"example.txt", 5934, 26984457539},
+		{"input.txt", 343441, 1569108373832},
+	} {
+		school := readFile(t, tc.path)
+		for days, want := range map[int]uint64{80: tc.part1, 256: tc.part2} {
+			if got, ok := memoPopulation(school, days); !ok || got != want {
+				t.Errorf("%s, day %d: memo counts %d (fits in uint64: %t), want %d", tc.path, days, got, ok, want)
+			}
+			if got := populationAt(school, days); !got.IsUint64() || got.Uint64() != want {
+				t.Errorf("%s, day %d: matrix counts %s, want %d", tc.path, days, got, want)
+			}
+		}
+	}
+}
+
+func TestFastForwardPastUint64(t *testing.T) {
+	const days = 500
+	for _, sh := range readFile(t, "example.txt") {
+		want := toBigHistogram(sh.histogram())
+		edges := sh.species.transition()
+		for d := 0; d < days; d++ {
+			want = bigStep(edges, want)
+		}
+		if want.total().IsUint64() {
+			t.Fatalf("Day %d: %s fish still fit in uint64", days, want.total())
+		}
+
+		got := fastForward(sh, days)
+		for state := range want {
+			if got[state].Cmp(want[state]) != 0 {
+				t.Errorf("Day %d, state %d: fastForward counts %s fish, bigStep %s", days, state, got[state], want[state])
+			}
+		}
+	}
+}
+
+func TestMemoDetectsOverflow(t *testing.T) {
+	if n, ok := memoPopulation(readFile(t, "example.txt"), 500); ok {
+		t.Errorf("Day 500: memo claims %d fish fit in uint64", n)
+	}
+}
+
+func TestReachDeadSchool(t *testing.T) {
+	sterile := species{name: "sterile", reset: 6, newborn: 8, deathAge: 60}
+	school := readFile(t, "input.txt")
+	for i := range school {
+		school[i].species = sterile
+	}
+	if day, ok := firstDayReaching(school, big.NewInt(1000)); ok {
+		t.Errorf("A sterile school reaches 1000 fish on day %d", day)
+	}
+}
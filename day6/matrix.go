@@ -0,0 +1,230 @@
+package main
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// The school as a histogram of states: h[s] is the number of fish in the state s (see species.state).
+// A day is linear in the histogram, so n days is the n-th power of the transition matrix, computed with O(log n)
+// multiplications. Lanternfish have 9 states, but a mortal species has a state per timer and age, so for the big
+// state spaces the engine steps day by day through the sparse transitions instead.
+//
+// The counts grow by ~9% a day, so they outgrow uint64 after ~450 days. Everything is computed in uint64 first and
+// only when an operation overflows, it is computed again with math/big.
+const maxMatrixStates = 64
+
+type histogram []uint64
+
+type matrix [][]uint64
+
+func newMatrix(n int) matrix {
+	m := make(matrix, n)
+	for i := range m {
+		m[i] = make([]uint64, n)
+	}
+	return m
+}
+
+// toMatrix makes m[to][from] the number of fish in the state `to` made by a fish in the state `from` in a day
+func toMatrix(n int, edges []edge) matrix {
+	m := newMatrix(n)
+	for _, e := range edges {
+		m[e.to][e.from] += e.weight
+	}
+	return m
+}
+
+func identity(n int) matrix {
+	m := newMatrix(n)
+	for i := 0; i < n; i++ {
+		m[i][i] = 1
+	}
+	return m
+}
+
+// mulAdd returns acc + a*b, false on overflow
+func mulAdd(acc, a, b uint64) (uint64, bool) {
+	hi, lo := bits.Mul64(a, b)
+	if hi != 0 {
+		return 0, false
+	}
+	sum, carry := bits.Add64(acc, lo, 0)
+	return sum, carry == 0
+}
+
+// mul returns false if any of the products or sums overflows
+func (a matrix) mul(b matrix) (matrix, bool) {
+	n := len(a)
+	m := newMatrix(n)
+	for i := 0; i < n; i++ {
+		for k := 0; k < n; k++ {
+			if a[i][k] == 0 {
+				continue
+			}
+			for j := 0; j < n; j++ {
+				var ok bool
+				if m[i][j], ok = mulAdd(m[i][j], a[i][k], b[k][j]); !ok {
+					return m, false
+				}
+			}
+		}
+	}
+	return m, true
+}
+
+// pow by squaring, returns false on overflow
+func (a matrix) pow(n int) (matrix, bool) {
+	result := identity(len(a))
+	for ok := true; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			if result, ok = result.mul(a); !ok {
+				return result, false
+			}
+		}
+		if n > 1 {
+			if a, ok = a.mul(a); !ok {
+				return a, false
+			}
+		}
+	}
+	return result, true
+}
+
+// apply returns the histogram after the matrix, false on overflow
+func (a matrix) apply(h histogram) (histogram, bool) {
+	result := make(histogram, len(h))
+	for i := range a {
+		for j := range a[i] {
+			var ok bool
+			if result[i], ok = mulAdd(result[i], a[i][j], h[j]); !ok {
+				return result, false
+			}
+		}
+	}
+	return result, true
+}
+
+// step moves the histogram a day forward, false on overflow
+func step(edges []edge, h histogram) (histogram, bool) {
+	result := make(histogram, len(h))
+	for _, e := range edges {
+		var ok bool
+		if result[e.to], ok = mulAdd(result[e.to], e.weight, h[e.from]); !ok {
+			return result, false
+		}
+	}
+	return result, true
+}
+
+type bigHistogram []*big.Int
+
+type bigMatrix [][]*big.Int
+
+func toBigMatrix(a matrix) bigMatrix {
+	m := make(bigMatrix, len(a))
+	for i := range a {
+		m[i] = make([]*big.Int, len(a[i]))
+		for j := range a[i] {
+			m[i][j] = new(big.Int).SetUint64(a[i][j])
+		}
+	}
+	return m
+}
+
+func toBigHistogram(h histogram) bigHistogram {
+	result := make(bigHistogram, len(h))
+	for i, n := range h {
+		result[i] = new(big.Int).SetUint64(n)
+	}
+	return result
+}
+
+func (a bigMatrix) mul(b bigMatrix) bigMatrix {
+	n := len(a)
+	m := toBigMatrix(newMatrix(n))
+	product := new(big.Int)
+	for i := 0; i < n; i++ {
+		for k := 0; k < n; k++ {
+			if a[i][k].Sign() == 0 {
+				continue
+			}
+			for j := 0; j < n; j++ {
+				m[i][j].Add(m[i][j], product.Mul(a[i][k], b[k][j]))
+			}
+		}
+	}
+	return m
+}
+
+func (a bigMatrix) pow(n int) bigMatrix {
+	result := toBigMatrix(identity(len(a)))
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			result = result.mul(a)
+		}
+		if n > 1 {
+			a = a.mul(a)
+		}
+	}
+	return result
+}
+
+func (a bigMatrix) apply(h bigHistogram) bigHistogram {
+	result := toBigHistogram(make(histogram, len(h)))
+	product := new(big.Int)
+	for i := range a {
+		for j := range a[i] {
+			result[i].Add(result[i], product.Mul(a[i][j], h[j]))
+		}
+	}
+	return result
+}
+
+func bigStep(edges []edge, h bigHistogram) bigHistogram {
+	result := toBigHistogram(make(histogram, len(h)))
+	product := new(big.Int)
+	for _, e := range edges {
+		result[e.to].Add(result[e.to], product.Mul(new(big.Int).SetUint64(e.weight), h[e.from]))
+	}
+	return result
+}
+
+func (h bigHistogram) total() *big.Int {
+	sum := new(big.Int)
+	for _, n := range h {
+		sum.Add(sum, n)
+	}
+	return sum
+}
+
+// fastForward returns the exact histogram of the shoal after the given number of days
+func fastForward(sh shoal, days int) bigHistogram {
+	sp := sh.species
+	edges := sp.transition()
+	h := sh.histogram()
+
+	if sp.states() <= maxMatrixStates {
+		if m, ok := toMatrix(sp.states(), edges).pow(days); ok {
+			if result, ok := m.apply(h); ok {
+				return toBigHistogram(result)
+			}
+		}
+		return toBigMatrix(toMatrix(sp.states(), edges)).pow(days).apply(toBigHistogram(h))
+	}
+
+	// day by day, switching to math/big at the first overflow
+	day := 0
+	for ; day < days; day++ {
+		next, ok := step(edges, h)
+		if !ok {
+			break
+		}
+		h = next
+	}
+	exact := toBigHistogram(h)
+	for ; day < days; day++ {
+		exact = bigStep(edges, exact)
+	}
+	return exact
+}
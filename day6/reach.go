@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math/big"
+)
+
+const (
+	// maxSearchDays bounds the search for the schools which stop growing, e.g. with spawn=0
+	maxSearchDays = 1 << 32
+	// maxScanDays bounds the day by day scan of the mortal schools
+	maxScanDays = 1 << 20
+)
+
+// firstDayReaching returns the first day the population is at least the threshold, false if it never gets there.
+//
+// Immortal fish never disappear, so the population never goes down. Doubling the day until the population reaches
+// the threshold, and then bisecting the last interval, takes O(log day) calls to populationAt.
+// A mortal school can shrink, so it is scanned day by day instead.
+func firstDayReaching(school []shoal, threshold *big.Int) (int, bool) {
+	for _, sh := range school {
+		if sh.species.mortal() {
+			return scanReaching(school, threshold)
+		}
+	}
+
+	if populationAt(school, 0).Cmp(threshold) >= 0 {
+		return 0, true
+	}
+
+	// populationAt(lo) < threshold <= populationAt(hi)
+	lo, hi := 0, 1
+	for populationAt(school, hi).Cmp(threshold) < 0 {
+		if hi >= maxSearchDays {
+			return 0, false
+		}
+		lo, hi = hi, hi*2
+	}
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if populationAt(school, mid).Cmp(threshold) < 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi, true
+}
+
+// scanReaching steps a mortal school day by day, a dead school never reaches a positive threshold
+func scanReaching(school []shoal, threshold *big.Int) (int, bool) {
+	edges := make([][]edge, len(school))
+	hs := make([]bigHistogram, len(school))
+	for i, sh := range school {
+		edges[i] = sh.species.transition()
+		hs[i] = toBigHistogram(sh.histogram())
+	}
+	for day := 0; day <= maxScanDays; day++ {
+		if day > 0 {
+			for i := range hs {
+				hs[i] = bigStep(edges[i], hs[i])
+			}
+		}
+		population := new(big.Int)
+		for _, h := range hs {
+			population.Add(population, h.total())
+		}
+		if population.Cmp(threshold) >= 0 {
+			return day, true
+		}
+		if population.Sign() == 0 {
+			return 0, false
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// sparkRamp goes from the smallest to the largest population, on a log scale
+const sparkRamp = "_.-:=+*#%@"
+
+const sparkWidth = 72
+
+// snapshot of the school at the end of a day, the fish counted by timer over all the species and ages
+type snapshot struct {
+	Day        int        `json:"day"`
+	Population *big.Int   `json:"population"`
+	Timers     []*big.Int `json:"timers"`
+}
+
+// timeSeries steps the whole school day by day, from day 0 to the horizon
+func timeSeries(school []shoal, horizon int) []snapshot {
+	timers := 0
+	edges := make([][]edge, len(school))
+	hs := make([]bigHistogram, len(school))
+	for i, sh := range school {
+		if sh.species.timers() > timers {
+			timers = sh.species.timers()
+		}
+		edges[i] = sh.species.transition()
+		hs[i] = toBigHistogram(sh.histogram())
+	}
+
+	series := make([]snapshot, 0, horizon+1)
+	for day := 0; day <= horizon; day++ {
+		if day > 0 {
+			for i := range hs {
+				hs[i] = bigStep(edges[i], hs[i])
+			}
+		}
+		s := snapshot{Day: day, Population: new(big.Int), Timers: toBigHistogram(make(histogram, timers))}
+		for i, sh := range school {
+			for state, n := range hs[i] {
+				s.Timers[state%sh.species.timers()].Add(s.Timers[state%sh.species.timers()], n)
+				s.Population.Add(s.Population, n)
+			}
+		}
+		series = append(series, s)
+	}
+	return series
+}
+
+func writeCSV(w io.Writer, series []snapshot) error {
+	out := csv.NewWriter(w)
+	header := []string{"day", "population"}
+	for t := range series[0].Timers {
+		header = append(header, fmt.Sprintf("timer%d", t))
+	}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	for _, s := range series {
+		row := []string{strconv.Itoa(s.Day), s.Population.String()}
+		for _, n := range s.Timers {
+			row = append(row, n.String())
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func writeJSON(w io.Writer, series []snapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(series)
+}
+
+// sparkline squeezes the series into sparkWidth characters, every character is the log of the population
+// at the end of its days, and adds the average daily growth rate over the last `window` days
+func sparkline(series []snapshot, window int) string {
+	logs := make([]float64, len(series))
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, s := range series {
+		logs[i] = log10(s.Population)
+		if math.IsInf(logs[i], -1) {
+			// extinct
+			continue
+		}
+		lo = math.Min(lo, logs[i])
+		hi = math.Max(hi, logs[i])
+	}
+
+	width := sparkWidth
+	if len(series) < width {
+		width = len(series)
+	}
+	var buf strings.Builder
+	for c := 0; c < width; c++ {
+		i := (c+1)*len(series)/width - 1
+		if math.IsInf(logs[i], -1) {
+			buf.WriteByte(' ')
+			continue
+		}
+		level := 0
+		if hi > lo {
+			level = int((logs[i] - lo) / (hi - lo) * float64(len(sparkRamp)-1))
+		}
+		buf.WriteByte(sparkRamp[level])
+	}
+
+	last := len(series) - 1
+	if window > last {
+		window = last
+	}
+	rate := "n/a"
+	if window > 0 && series[last-window].Population.Sign() > 0 && series[last].Population.Sign() > 0 {
+		rate = fmt.Sprintf("%.4f", math.Pow(10, (logs[last]-logs[last-window])/float64(window)))
+	}
+	return fmt.Sprintf("%s  10^%.1f .. 10^%.1f, daily growth over the last %d days: x%s",
+		buf.String(), lo, hi, window, rate)
+}
+
+// log10 of the big population, -Inf for 0
+func log10(n *big.Int) float64 {
+	if n.Sign() == 0 {
+		return math.Inf(-1)
+	}
+	f, _ := new(big.Float).SetInt(n).Float64()
+	if !math.IsInf(f, 0) {
+		return math.Log10(f)
+	}
+	// beyond float64, use the number of digits
+	digits := len(n.String())
+	lead, _ := strconv.ParseFloat(n.String()[:16], 64)
+	return float64(digits-16) + math.Log10(lead)
+}
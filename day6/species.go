@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// species defines the life-cycle of the fish
+type species struct {
+	name       string
+	reset      int // timer after spawning
+	newborn    int // timer of a freshly spawned fish
+	deathAge   int // the fish dies after living this many days, 0 for immortal
+	spawnCount int // fish spawned at once
+}
+
+// lanternfish from the puzzle
+var lanternfish = species{name: "lanternfish", reset: 6, newborn: 8, spawnCount: 1}
+
+func (sp species) validate() error {
+	switch {
+	case sp.reset < 0:
+		return fmt.Errorf("%s: reset timer can't be negative, got %d", sp.name, sp.reset)
+	case sp.newborn < 0:
+		return fmt.Errorf("%s: newborn timer can't be negative, got %d", sp.name, sp.newborn)
+	case sp.deathAge < 0:
+		return fmt.Errorf("%s: death age can't be negative, got %d", sp.name, sp.deathAge)
+	case sp.spawnCount < 0:
+		return fmt.Errorf("%s: spawn count can't be negative, got %d", sp.name, sp.spawnCount)
+	}
+	return nil
+}
+
+func (sp species) mortal() bool {
+	return sp.deathAge > 0
+}
+
+// The state of a fish is its timer and, for the mortal species, its age.
+// The fish from the input are considered newborn (age 0) on day 0.
+
+func (sp species) timers() int {
+	if sp.reset > sp.newborn {
+		return sp.reset + 1
+	}
+	return sp.newborn + 1
+}
+
+func (sp species) ages() int {
+	if sp.mortal() {
+		return sp.deathAge
+	}
+	return 1
+}
+
+func (sp species) states() int {
+	return sp.timers() * sp.ages()
+}
+
+func (sp species) state(timer, age int) int {
+	return age*sp.timers() + timer
+}
+
+// edge moves weight fish from one state to another in a day
+type edge struct {
+	from, to int
+	weight   uint64
+}
+
+// transition lists how a day changes every state: the timer goes down, or it resets and the fish spawns.
+// A mortal fish ages and dies at deathAge, but its spawn of the last day lives on.
+func (sp species) transition() []edge {
+	edges := make([]edge, 0, sp.states()+sp.ages())
+	for age := 0; age < sp.ages(); age++ {
+		nextAge, alive := 0, true
+		if sp.mortal() {
+			nextAge = age + 1
+			alive = nextAge < sp.deathAge
+		}
+		for timer := 0; timer < sp.timers(); timer++ {
+			from := sp.state(timer, age)
+			if timer > 0 {
+				if alive {
+					edges = append(edges, edge{from, sp.state(timer-1, nextAge), 1})
+				}
+				continue
+			}
+			if alive {
+				edges = append(edges, edge{from, sp.state(sp.reset, nextAge), 1})
+			}
+			if sp.spawnCount > 0 {
+				edges = append(edges, edge{from, sp.state(sp.newborn, 0), uint64(sp.spawnCount)})
+			}
+		}
+	}
+	return edges
+}
+
+// shoal is the fish of a single species, a school can have many
+type shoal struct {
+	species species
+	fish    []int
+}
+
+func (sh shoal) histogram() histogram {
+	h := make(histogram, sh.species.states())
+	for _, f := range sh.fish {
+		h[sh.species.state(f, 0)]++
+	}
+	return h
+}
+
+// readSpecies parses a species per line, e.g.
+//
+//	# name reset=<timer> newborn=<timer> [death=<age>] [spawn=<count>]
+//	lanternfish reset=6 newborn=8
+//	salmon reset=3 newborn=5 death=20 spawn=4
+func readSpecies(r io.Reader) ([]species, error) {
+	result := make([]species, 0)
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		xs := strings.Fields(s.Text())
+		if len(xs) == 0 || strings.HasPrefix(xs[0], "#") {
+			continue
+		}
+		sp := species{name: xs[0], reset: -1, newborn: -1, spawnCount: 1}
+		for _, x := range xs[1:] {
+			kv := strings.SplitN(x, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("%s: want key=value, got %s", sp.name, x)
+			}
+			n, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return nil, fmt.Errorf("%s: can't parse %s as a number: %w", sp.name, kv[1], err)
+			}
+			switch kv[0] {
+			case "reset":
+				sp.reset = n
+			case "newborn":
+				sp.newborn = n
+			case "death":
+				sp.deathAge = n
+			case "spawn":
+				sp.spawnCount = n
+			default:
+				return nil, fmt.Errorf("%s: unknown parameter %s", sp.name, kv[0])
+			}
+		}
+		if err := sp.validate(); err != nil {
+			return nil, err
+		}
+		result = append(result, sp)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no species defined")
+	}
+	return result, nil
+}
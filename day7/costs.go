@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// cost is a way for the crabs to burn fuel
+type cost struct {
+	name string
+	// convex costs have no local minima other than the global one, so ternaryMin finds it
+	convex bool
+	fn     func(x int, crabs []int) int
+	// shape is the fuel the i-th crab burns to move the distance d, for the crabs on the plane
+	shape func(i int, d float64) float64
+	// prefixed optionally builds the O(1) evaluation of fn from the prefix sums of the crabs in [lb, ub]
+	prefixed func(crabs []int, lb, ub int) func(x int) int
+	// fast is an optional closed-form optimiser
+	fast func(crabs []int) (minVal, minX int)
+}
+
+// costs lists the registered cost functions, the weights (one per crab) and the limit parametrise some of them
+func costs(weights []int, limit int) map[string]cost {
+	registry := map[string]cost{}
+	for _, c := range []cost{
+		{name: "linear", convex: true, fn: diff, fast: medianMin, shape: func(_ int, d float64) float64 {
+			return d
+		}, prefixed: func(crabs []int, lb, ub int) func(int) int {
+			p := newPrefix(crabs, nil, lb, ub)
+			return func(x int) int {
+				return p.absDiff(x, lb, ub)
+			}
+		}},
+		{name: "triangular", convex: true, fn: sequenceSum, fast: meanMin, shape: func(_ int, d float64) float64 {
+			return d * (d + 1) / 2
+		}, prefixed: func(crabs []int, lb, ub int) func(int) int {
+			p := newPrefix(crabs, nil, lb, ub)
+			return func(x int) int {
+				// every d^2 + |d| is even
+				return (p.squares(x, lb, ub) + p.absDiff(x, lb, ub)) / 2
+			}
+		}},
+		{name: "quadratic", convex: true, fn: squareSum, shape: func(_ int, d float64) float64 {
+			return d * d
+		}, prefixed: func(crabs []int, lb, ub int) func(int) int {
+			p := newPrefix(crabs, nil, lb, ub)
+			return func(x int) int {
+				return p.squares(x, lb, ub)
+			}
+		}},
+		{name: "weighted", convex: true, fn: func(x int, crabs []int) int {
+			return weightedDiff(x, crabs, weights)
+		}, shape: func(i int, d float64) float64 {
+			return float64(weights[i]) * d
+		}, prefixed: func(crabs []int, lb, ub int) func(int) int {
+			p := newPrefix(crabs, weights, lb, ub)
+			return func(x int) int {
+				return p.absDiff(x, lb, ub)
+			}
+		}},
+		{name: "capped", convex: false, fn: func(x int, crabs []int) int {
+			return cappedDiff(x, crabs, limit)
+		}, shape: func(_ int, d float64) float64 {
+			return math.Min(d, float64(limit))
+		}, prefixed: func(crabs []int, lb, ub int) func(int) int {
+			p := newPrefix(crabs, nil, lb, ub)
+			return func(x int) int {
+				// the crabs further than the limit burn just the limit
+				near, _, _ := p.between(x-limit, x+limit)
+				return p.absDiff(x, x-limit, x+limit) + (len(crabs)-near)*limit
+			}
+		}},
+	} {
+		registry[c.name] = c
+	}
+	return registry
+}
+
+func costNames(registry map[string]cost) string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func selectCosts(registry map[string]cost, in string) ([]cost, error) {
+	selected := make([]cost, 0)
+	for _, name := range strings.Split(in, ",") {
+		c, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown cost function %s, want one of: %s", name, costNames(registry))
+		}
+		selected = append(selected, c)
+	}
+	return selected, nil
+}
+
+func squareSum(x int, crabs []int) int {
+	sum := 0
+	for _, crab := range crabs {
+		d := x - crab
+		sum += d * d
+	}
+	return sum
+}
+
+// weightedDiff is diff with the distance of every crab multiplied by its weight, the weights can't be negative
+func weightedDiff(x int, crabs []int, weights []int) int {
+	sum := 0
+	for i, crab := range crabs {
+		sum += weights[i] * abs(x-crab)
+	}
+	return sum
+}
+
+// cappedDiff is diff, but no crab burns more than the limit
+func cappedDiff(x int, crabs []int, limit int) int {
+	sum := 0
+	for _, crab := range crabs {
+		d := abs(x - crab)
+		if d > limit {
+			d = limit
+		}
+		sum += d
+	}
+	return sum
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"io"
 	"log"
@@ -12,16 +13,114 @@ import (
 )
 
 func main() {
+	optimiser := flag.String("optimiser", "auto", "how to find the minimum: auto, brute, fast (closed form) or ternary (convex only)")
+	check := flag.Bool("check", false, "cross-validate the optimisers against the brute force")
+	costList := flag.String("cost", "linear,triangular", "comma separated cost functions: linear, triangular, quadratic, weighted, capped")
+	weightsFile := flag.String("weights", "", "file with the weight of every crab for the weighted cost, 1 each by default")
+	limit := flag.Int("cap", 10, "most fuel a crab burns with the capped cost")
+	dist := flag.String("distance", "", "align the x,y crabs on the plane: manhattan, chebyshev or euclidean")
+	plot := flag.Bool("plot", false, "chart the cost curves over the whole range")
+	svg := flag.String("svg", "", "write the cost curves to this SVG file")
+	usePrefix := flag.Bool("prefix", true, "evaluate the costs in O(1) with the prefix sums of the crabs by position")
+	flag.Parse()
+	if *limit < 0 {
+		log.Fatalf("Can't cap the fuel at a negative %d\n", *limit)
+	}
+
+	selectFor := func(n int) []cost {
+		selected, err := selectCosts(costs(readWeights(*weightsFile, n), *limit), *costList)
+		if err != nil {
+			log.Fatalf("Can't select the costs: %v\n", err)
+		}
+		return selected
+	}
+
+	if *dist != "" {
+		d, ok := distances[*dist]
+		if !ok {
+			log.Fatalf("Unknown distance: %s\n", *dist)
+		}
+		crabs := readPlane()
+		for _, c := range selectFor(len(crabs)) {
+			alignPlane(c, d, crabs, *optimiser, *check)
+		}
+		return
+	}
+
 	crabs, min, max := read()
-	for _, testCase := range []struct {
-		desc string
-		fn   func(int, []int) int
-	}{
-		{"diff", diff},
-		{"seqsum", sequenceSum},
-	} {
-		minVal, minX := findMin(testCase.desc, testCase.fn, crabs, min, max)
-		fmt.Printf(":: Min f_%s(%04d) => %d\n", testCase.desc, minX, minVal)
+	selected := selectFor(len(crabs))
+	if *usePrefix {
+		for i, c := range selected {
+			selected[i] = withPrefix(c, crabs, min, max, *check)
+		}
+	}
+	for _, c := range selected {
+		var minVal, minX int
+		switch *optimiser {
+		case "auto":
+			minVal, minX = optimise(c, crabs, min, max)
+		case "brute":
+			minVal, minX = findMin(c.name, c.fn, crabs, min, max)
+		case "fast":
+			if c.fast == nil {
+				log.Fatalf("f_%s has no closed-form optimiser\n", c.name)
+			}
+			minVal, minX = c.fast(crabs)
+		case "ternary":
+			if !c.convex {
+				log.Fatalf("f_%s isn't convex, ternary search can miss the minimum\n", c.name)
+			}
+			minVal, minX = ternaryMin(c.fn, crabs, min, max)
+		default:
+			log.Fatalf("Unknown optimiser: %s\n", *optimiser)
+		}
+
+		if *check {
+			crossValidate(c, crabs, min, max)
+		}
+
+		fmt.Printf(":: Min f_%s(%04d) => %d\n", c.name, minX, minVal)
+	}
+
+	if *plot || *svg != "" {
+		curves := make([]curve, 0, len(selected))
+		for _, c := range selected {
+			curves = append(curves, costCurve(c, crabs, min, max))
+		}
+		if *plot {
+			fmt.Println()
+			printCharts(os.Stdout, curves)
+		}
+		if *svg != "" {
+			if err := writeSVG(*svg, curves); err != nil {
+				log.Fatalf("Can't write %s: %v\n", *svg, err)
+			}
+		}
+	}
+}
+
+// optimise picks the fastest optimiser the cost supports
+func optimise(c cost, crabs []int, min, max int) (minVal, minX int) {
+	if c.fast != nil {
+		return c.fast(crabs)
+	}
+	if c.convex {
+		return ternaryMin(c.fn, crabs, min, max)
+	}
+	return findMin(c.name, c.fn, crabs, min, max)
+}
+
+func crossValidate(c cost, crabs []int, min, max int) {
+	want, _ := findMin(c.name, c.fn, crabs, min, max)
+	if c.fast != nil {
+		if got, _ := c.fast(crabs); got != want {
+			log.Fatalf("f_%s: brute force finds %d, fast %d\n", c.name, want, got)
+		}
+	}
+	if c.convex {
+		if got, _ := ternaryMin(c.fn, crabs, min, max); got != want {
+			log.Fatalf("f_%s: brute force finds %d, ternary %d\n", c.name, want, got)
+		}
 	}
 }
 
@@ -32,7 +131,7 @@ func findMin(
 	lb int,
 	ub int,
 ) (minVal, minX int) {
-	minVal = math.MaxInt32
+	minVal = math.MaxInt
 	if len(seq) < 20 {
 		fmt.Println()
 	}
@@ -107,15 +206,45 @@ func read() (crabs []int, min, max int) {
 	if err := s.Err(); err != nil {
 		log.Fatalf("Scanner errors: %v\n", err)
 	}
+	if len(crabs) == 0 {
+		log.Fatalf("No crabs in the input\n")
+	}
 
 	return
 }
 
+func readWeights(path string, n int) []int {
+	weights := make([]int, n)
+	if path == "" {
+		for i := range weights {
+			weights[i] = 1
+		}
+		return weights
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Can't read %s: %v\n", path, err)
+	}
+	xs := strings.Split(strings.TrimSpace(string(content)), ",")
+	if len(xs) != n {
+		log.Fatalf("Want a weight for each of the %d crabs, got %d\n", n, len(xs))
+	}
+	for i, x := range xs {
+		w, err := strconv.Atoi(x)
+		if err != nil || w < 0 {
+			log.Fatalf("Can't parse weight[%d] = %s as a non-negative number\n", i, x)
+		}
+		weights[i] = w
+	}
+	return weights
+}
+
 func selectInput() (reader io.Reader, closer func()) {
-	if len(os.Args) > 1 {
-		f, err := os.Open(os.Args[1])
+	if flag.NArg() > 0 {
+		f, err := os.Open(flag.Arg(0))
 		if err != nil {
-			log.Fatalf("Can't open %s: %v\n", os.Args[1], err)
+			log.Fatalf("Can't open %s: %v\n", flag.Arg(0), err)
 		}
 		return f, func() {
 			_ = f.Close()
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"sort"
+)
+
+// medianMin minimises diff: moving x towards the median never increases the total distance,
+// since more crabs are on the median's side of x than on the other side.
+func medianMin(crabs []int) (minVal, minX int) {
+	sorted := append([]int(nil), crabs...)
+	sort.Ints(sorted)
+	minX = sorted[(len(sorted)-1)/2]
+	return diff(minX, crabs), minX
+}
+
+// meanMin minimises sequenceSum. The cost is sum((d^2 + |d|) / 2), the derivative of which is zero somewhere
+// within half a step from the mean, so the best integer is within [floor(mean - 1/2), ceil(mean + 1/2)].
+func meanMin(crabs []int) (minVal, minX int) {
+	sum := 0
+	for _, crab := range crabs {
+		sum += crab
+	}
+	n := len(crabs)
+	// floor((2*sum - n) / 2n) and ceil((2*sum + n) / 2n), exact in integers
+	lb := floorDiv(2*sum-n, 2*n)
+	ub := -floorDiv(-(2*sum + n), 2*n)
+	return ternaryMin(sequenceSum, crabs, lb, ub)
+}
+
+// ternaryMin finds the minimum of any convex cost in [lb, ub] with O(log(ub - lb)) evaluations.
+// For a convex function with f(m1) == f(m2), a minimum is between m1 and m2, so the plateaus are fine.
+func ternaryMin(fn func(int, []int) int, crabs []int, lb, ub int) (minVal, minX int) {
+	for ub-lb > 2 {
+		m1 := lb + (ub-lb)/3
+		m2 := ub - (ub-lb)/3
+		f1, f2 := fn(m1, crabs), fn(m2, crabs)
+		switch {
+		case f1 < f2:
+			ub = m2 - 1
+		case f1 > f2:
+			lb = m1 + 1
+		default:
+			lb, ub = m1, m2
+		}
+	}
+
+	minVal, minX = fn(lb, crabs), lb
+	for x := lb + 1; x <= ub; x++ {
+		if y := fn(x, crabs); y < minVal {
+			minVal, minX = y, x
+		}
+	}
+	return
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// point is a crab, or the meeting point, on the sea floor
+type point struct {
+	x, y int
+}
+
+// distance between two points, the fuel shape of the cost turns it into fuel
+type distance func(a, b point) float64
+
+var distances = map[string]distance{
+	"manhattan": func(a, b point) float64 {
+		return float64(abs(a.x-b.x) + abs(a.y-b.y))
+	},
+	"chebyshev": func(a, b point) float64 {
+		return float64(max(abs(a.x-b.x), abs(a.y-b.y)))
+	},
+	"euclidean": func(a, b point) float64 {
+		return math.Hypot(float64(a.x-b.x), float64(a.y-b.y))
+	},
+}
+
+// planeCost is the fuel all the crabs burn to meet at p
+func planeCost(c cost, dist distance, p point, crabs []point) float64 {
+	sum := 0.0
+	for i, crab := range crabs {
+		sum += c.shape(i, dist(p, crab))
+	}
+	return sum
+}
+
+// planeMin scans every column of the bounding box of the crabs. A convex shape of a distance is convex, and so is
+// its restriction to a column, so with ternary the column takes O(log(maxY - minY)) evaluations.
+func planeMin(c cost, dist distance, crabs []point, ternary bool) (minVal float64, minP point) {
+	lo, hi := crabs[0], crabs[0]
+	for _, crab := range crabs {
+		lo = point{min(lo.x, crab.x), min(lo.y, crab.y)}
+		hi = point{max(hi.x, crab.x), max(hi.y, crab.y)}
+	}
+
+	minVal = math.Inf(1)
+	for x := lo.x; x <= hi.x; x++ {
+		fn := func(y int) float64 {
+			return planeCost(c, dist, point{x, y}, crabs)
+		}
+		var val float64
+		var y int
+		if ternary {
+			val, y = ternaryColumnMin(fn, lo.y, hi.y)
+		} else {
+			val, y = bruteColumnMin(fn, lo.y, hi.y)
+		}
+		if val < minVal {
+			minVal, minP = val, point{x, y}
+		}
+	}
+	return
+}
+
+// ternaryColumnMin is ternaryMin over a single column
+func ternaryColumnMin(fn func(int) float64, lb, ub int) (minVal float64, minY int) {
+	for ub-lb > 2 {
+		m1 := lb + (ub-lb)/3
+		m2 := ub - (ub-lb)/3
+		f1, f2 := fn(m1), fn(m2)
+		switch {
+		case f1 < f2:
+			ub = m2 - 1
+		case f1 > f2:
+			lb = m1 + 1
+		default:
+			lb, ub = m1, m2
+		}
+	}
+	return bruteColumnMin(fn, lb, ub)
+}
+
+func bruteColumnMin(fn func(int) float64, lb, ub int) (minVal float64, minY int) {
+	minVal, minY = fn(lb), lb
+	for y := lb + 1; y <= ub; y++ {
+		if v := fn(y); v < minVal {
+			minVal, minY = v, y
+		}
+	}
+	return
+}
+
+// alignPlane prints the best meeting point of the crabs for the cost
+func alignPlane(c cost, dist distance, crabs []point, optimiser string, check bool) {
+	var ternary bool
+	switch optimiser {
+	case "auto":
+		ternary = c.convex
+	case "brute":
+		ternary = false
+	case "ternary":
+		if !c.convex {
+			log.Fatalf("f_%s isn't convex, ternary search can miss the minimum\n", c.name)
+		}
+		ternary = true
+	default:
+		log.Fatalf("Optimiser %s doesn't work on the plane, want auto, brute or ternary\n", optimiser)
+	}
+
+	minVal, minP := planeMin(c, dist, crabs, ternary)
+	if check && ternary {
+		// the float sums don't add up exactly the same in every order
+		if want, _ := planeMin(c, dist, crabs, false); math.Abs(minVal-want) > 1e-9*math.Max(1, want) {
+			log.Fatalf("f_%s: brute force finds %f, ternary %f\n", c.name, want, minVal)
+		}
+	}
+
+	fmt.Printf(":: Min f_%s(%04d,%04d) => %s\n", c.name, minP.x, minP.y, formatFuel(minVal))
+}
+
+// formatFuel prints the whole numbers without the decimals
+func formatFuel(f float64) string {
+	if f == math.Trunc(f) {
+		return strconv.FormatFloat(f, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(f, 'f', 3, 64)
+}
+
+// readPlane reads a crab per line as x,y. A single line is the 1-D input, all the crabs at y = 0.
+func readPlane() []point {
+	reader, closer := selectInput()
+	defer closer()
+	s := bufio.NewScanner(reader)
+
+	lines := make([]string, 0)
+	for s.Scan() {
+		if line := strings.TrimSpace(s.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := s.Err(); err != nil {
+		log.Fatalf("Scanner errors: %v\n", err)
+	}
+	if len(lines) == 0 {
+		log.Fatalf("No crabs in the input\n")
+	}
+
+	crabs := make([]point, 0, len(lines))
+	if len(lines) == 1 {
+		for i, x := range strings.Split(lines[0], ",") {
+			crabs = append(crabs, point{x: parseCoord(i, x)})
+		}
+		return crabs
+	}
+	for i, line := range lines {
+		xy := strings.Split(line, ",")
+		if len(xy) != 2 {
+			log.Fatalf("Want crab[%d] as x,y, got %s\n", i, line)
+		}
+		crabs = append(crabs, point{parseCoord(i, xy[0]), parseCoord(i, xy[1])})
+	}
+	return crabs
+}
+
+func parseCoord(i int, x string) int {
+	n, err := strconv.Atoi(x)
+	if err != nil {
+		log.Fatalf("Can't parse crab[%d] = %s as a number: %v", i, x, err)
+	}
+	return n
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	plotWidth  = 48
+	plotHeight = 12
+	plotGutter = "   "
+
+	svgWidth  = 480
+	svgHeight = 320
+	svgMargin = 40
+)
+
+// curve is the cost at every x from lb to ub
+type curve struct {
+	name       string
+	lb         int
+	fuel       []int
+	minX, minV int
+	maxV       int
+}
+
+func costCurve(c cost, crabs []int, lb, ub int) curve {
+	cv := curve{name: c.name, lb: lb, fuel: make([]int, 0, ub-lb+1), minX: lb}
+	for x := lb; x <= ub; x++ {
+		v := c.fn(x, crabs)
+		if x == lb || v < cv.minV {
+			cv.minX, cv.minV = x, v
+		}
+		if x == lb || v > cv.maxV {
+			cv.maxV = v
+		}
+		cv.fuel = append(cv.fuel, v)
+	}
+	return cv
+}
+
+func (cv curve) ub() int {
+	return cv.lb + len(cv.fuel) - 1
+}
+
+// level scales the fuel to [0, levels - 1]
+func (cv curve) level(v int, levels int) int {
+	if cv.maxV == cv.minV {
+		return 0
+	}
+	return int(float64(v-cv.minV) / float64(cv.maxV-cv.minV) * float64(levels-1))
+}
+
+// chart renders the curve as plotWidth columns, each the cheapest x it covers, with the minimum marked by X
+func (cv curve) chart() []string {
+	width := plotWidth
+	if len(cv.fuel) < width {
+		width = len(cv.fuel)
+	}
+	grid := make([][]byte, plotHeight)
+	for r := range grid {
+		grid[r] = []byte(strings.Repeat(" ", width))
+	}
+	minCol := 0
+	for col := 0; col < width; col++ {
+		from, to := col*len(cv.fuel)/width, (col+1)*len(cv.fuel)/width
+		best := cv.fuel[from]
+		for i := from; i < to; i++ {
+			if cv.fuel[i] < best {
+				best = cv.fuel[i]
+			}
+			if cv.lb+i == cv.minX {
+				minCol = col
+			}
+		}
+		grid[plotHeight-1-cv.level(best, plotHeight)][col] = '*'
+	}
+	grid[plotHeight-1][minCol] = 'X'
+
+	lines := []string{
+		fmt.Sprintf("f_%s", cv.name),
+		fmt.Sprintf("max %d", cv.maxV),
+	}
+	for _, row := range grid {
+		lines = append(lines, "|"+string(row))
+	}
+	lines = append(lines, "+"+strings.Repeat("-", width))
+	axis := []byte(strings.Repeat(" ", width+1))
+	axis[1+minCol] = '^'
+	lines = append(lines, string(axis))
+	lb, ub := strconv.Itoa(cv.lb), strconv.Itoa(cv.ub())
+	lines = append(lines, lb+strings.Repeat(" ", max(1, width+1-len(lb)-len(ub)))+ub)
+	lines = append(lines, fmt.Sprintf("min %d at %d", cv.minV, cv.minX))
+	return lines
+}
+
+// printCharts puts the charts of the curves side by side
+func printCharts(w io.Writer, curves []curve) {
+	charts := make([][]string, len(curves))
+	widths := make([]int, len(curves))
+	for i, cv := range curves {
+		charts[i] = cv.chart()
+		for _, line := range charts[i] {
+			widths[i] = max(widths[i], len(line))
+		}
+	}
+	for row := range charts[0] {
+		parts := make([]string, len(charts))
+		for i := range charts {
+			parts[i] = fmt.Sprintf("%-*s", widths[i], charts[i][row])
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(parts, plotGutter), " "))
+	}
+}
+
+// writeSVG draws a panel per curve, side by side, the minimum marked with a red dot
+func writeSVG(path string, curves []curve) error {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace" font-size="12">`+"\n",
+		svgWidth*len(curves), svgHeight)
+	inner := func(size int) float64 {
+		return float64(size - 2*svgMargin)
+	}
+	for i, cv := range curves {
+		left := i * svgWidth
+		px := func(x int) float64 {
+			if cv.ub() == cv.lb {
+				return float64(left + svgMargin)
+			}
+			return float64(left+svgMargin) + float64(x-cv.lb)/float64(cv.ub()-cv.lb)*inner(svgWidth)
+		}
+		py := func(v int) float64 {
+			if cv.maxV == cv.minV {
+				return float64(svgHeight - svgMargin)
+			}
+			return float64(svgHeight-svgMargin) - float64(v-cv.minV)/float64(cv.maxV-cv.minV)*inner(svgHeight)
+		}
+
+		fmt.Fprintf(&buf, `<g>`+"\n")
+		fmt.Fprintf(&buf, `<text x="%d" y="%d">f_%s</text>`+"\n", left+svgMargin, svgMargin/2, cv.name)
+		fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%.0f" height="%.0f" fill="none" stroke="#ccc"/>`+"\n",
+			left+svgMargin, svgMargin, inner(svgWidth), inner(svgHeight))
+		points := make([]string, 0, len(cv.fuel))
+		for j, v := range cv.fuel {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", px(cv.lb+j), py(v)))
+		}
+		fmt.Fprintf(&buf, `<polyline fill="none" stroke="steelblue" points="%s"/>`+"\n", strings.Join(points, " "))
+		fmt.Fprintf(&buf, `<circle cx="%.1f" cy="%.1f" r="4" fill="red"/>`+"\n", px(cv.minX), py(cv.minV))
+		fmt.Fprintf(&buf, `<text x="%d" y="%d">%d</text>`+"\n", left+svgMargin, svgHeight-svgMargin/2, cv.lb)
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="end">%d</text>`+"\n",
+			left+svgWidth-svgMargin, svgHeight-svgMargin/2, cv.ub())
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="middle">min %d at %d</text>`+"\n",
+			left+svgWidth/2, svgHeight-svgMargin/2, cv.minV, cv.minX)
+		fmt.Fprintf(&buf, `</g>`+"\n")
+	}
+	fmt.Fprintln(&buf, `</svg>`)
+	return os.WriteFile(path, []byte(buf.String()), 0644)
+}
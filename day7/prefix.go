@@ -0,0 +1,81 @@
+package main
+
+import (
+	"log"
+)
+
+// maxPrefixRange bounds the memory of the prefix sums, three ints per position
+const maxPrefixRange = 1 << 24
+
+// prefix sums of the crabs by position, in [lb, ub]. With them the cost at any x is a handful of range queries,
+// O(1) instead of O(crabs), after O(crabs + range) to build them.
+type prefix struct {
+	lb    int
+	count []int // count[i] is the number of crabs (or their weight) in [lb, lb + i)
+	sum   []int // ... their positions
+	sumSq []int // ... their squared positions
+}
+
+// newPrefix counts every crab weights[i] times, or once with no weights. An empty range has no crabs.
+func newPrefix(crabs []int, weights []int, lb, ub int) *prefix {
+	size := max(ub-lb+2, 1)
+	p := &prefix{lb: lb, count: make([]int, size), sum: make([]int, size), sumSq: make([]int, size)}
+	for i, crab := range crabs {
+		w := 1
+		if weights != nil {
+			w = weights[i]
+		}
+		at := crab - lb + 1
+		p.count[at] += w
+		p.sum[at] += w * crab
+		p.sumSq[at] += w * crab * crab
+	}
+	for i := 1; i < size; i++ {
+		p.count[i] += p.count[i-1]
+		p.sum[i] += p.sum[i-1]
+		p.sumSq[i] += p.sumSq[i-1]
+	}
+	return p
+}
+
+// between returns the count, sum and sum of squares of the crabs in [a, b]
+func (p *prefix) between(a, b int) (n, s, q int) {
+	from, to := max(a-p.lb, 0), min(b-p.lb+1, len(p.count)-1)
+	if from >= to {
+		return 0, 0, 0
+	}
+	return p.count[to] - p.count[from], p.sum[to] - p.sum[from], p.sumSq[to] - p.sumSq[from]
+}
+
+// absDiff is the sum of |x - crab| over the crabs in [a, b]
+func (p *prefix) absDiff(x, a, b int) int {
+	nl, sl, _ := p.between(a, min(b, x))
+	nr, sr, _ := p.between(max(a, x+1), b)
+	return nl*x - sl + sr - nr*x
+}
+
+// squares is the sum of (x - crab)^2 over the crabs in [a, b]
+func (p *prefix) squares(x, a, b int) int {
+	n, s, q := p.between(a, b)
+	return n*x*x - 2*x*s + q
+}
+
+// withPrefix swaps the cost function for its prefix sums evaluation, if the cost has one and the range is small
+// enough. With check, it compares the two at every x.
+func withPrefix(c cost, crabs []int, lb, ub int, check bool) cost {
+	if c.prefixed == nil || lb > ub || ub-lb+1 > maxPrefixRange {
+		return c
+	}
+	eval := c.prefixed(crabs, lb, ub)
+	if check {
+		for x := lb; x <= ub; x++ {
+			if want, got := c.fn(x, crabs), eval(x); want != got {
+				log.Fatalf("f_%s(%d): the loop over the crabs gives %d, the prefix sums %d\n", c.name, x, want, got)
+			}
+		}
+	}
+	c.fn = func(x int, _ []int) int {
+		return eval(x)
+	}
+	return c
+}
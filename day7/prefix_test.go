@@ -0,0 +1,82 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+const (
+	benchCrabs = 1000
+	benchRange = 2000
+)
+
+func randomCrabs(n, lb, ub int, seed int64) []int {
+	rnd := rand.New(rand.NewSource(seed))
+	crabs := make([]int, n)
+	for i := range crabs {
+		crabs[i] = lb + rnd.Intn(ub-lb+1)
+	}
+	return crabs
+}
+
+func TestPrefixMatchesLoop(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		crabs := randomCrabs(1+int(seed)*7, -50, 50, seed)
+		weights := randomCrabs(len(crabs), 0, 9, seed)
+		lb, ub := crabs[0], crabs[0]
+		for _, crab := range crabs {
+			lb, ub = min(lb, crab), max(ub, crab)
+		}
+		for _, c := range costs(weights, 7) {
+			eval := c.prefixed(crabs, lb, ub)
+			for x := lb; x <= ub; x++ {
+				if want, got := c.fn(x, crabs), eval(x); want != got {
+					t.Fatalf("seed %d: f_%s(%d) is %d, the prefix sums give %d", seed, c.name, x, want, got)
+				}
+			}
+		}
+	}
+}
+
+func TestPrefixEmptyRange(t *testing.T) {
+	p := newPrefix(nil, nil, 1, 0)
+	if n, _, _ := p.between(0, 1); n != 0 {
+		t.Errorf("An empty range counts %d crabs", n)
+	}
+}
+
+// benchmarkScan evaluates the cost at every x of the range, like the brute force does
+func benchmarkScan(b *testing.B, name string, prefixed bool) {
+	crabs := randomCrabs(benchCrabs, 0, benchRange-1, 1)
+	lb, ub := 0, benchRange-1
+	c := costs(nil, 10)[name]
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fn := func(x int) int {
+			return c.fn(x, crabs)
+		}
+		if prefixed {
+			// building the sums is part of the scan
+			fn = c.prefixed(crabs, lb, ub)
+		}
+		for x := lb; x <= ub; x++ {
+			_ = fn(x)
+		}
+	}
+}
+
+func BenchmarkCostLoop(b *testing.B) {
+	for _, name := range []string{"linear", "triangular", "capped"} {
+		b.Run(name, func(b *testing.B) {
+			benchmarkScan(b, name, false)
+		})
+	}
+}
+
+func BenchmarkCostPrefix(b *testing.B) {
+	for _, name := range []string{"linear", "triangular", "capped"} {
+		b.Run(name, func(b *testing.B) {
+			benchmarkScan(b, name, true)
+		})
+	}
+}
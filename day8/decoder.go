@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// wiring maps every wire to the segment it's connected to, wiring[i] is the segment of the wire 1<<i
+type wiring []int
+
+func (w wiring) translate(pattern segmentSet) segmentSet {
+	var result segmentSet
+	for wire, seg := range w {
+		if pattern.has(segment(1 << wire)) {
+			result |= segmentSet(1 << seg)
+		}
+	}
+	return result
+}
+
+func (w wiring) format(d display) string {
+	xs := make([]string, 0, len(w))
+	for wire, seg := range w {
+		xs = append(xs, fmt.Sprintf("%c->%s", segment(1<<wire).format(), d.names[seg]))
+	}
+	return strings.Join(xs, " ")
+}
+
+// decoder finds the wirings declaratively: every wire has a domain of the segments it can still be connected to,
+// and every pattern has to light up one of the glyphs. The constraints narrow the domains down, and when they get
+// stuck, the decoder tries every segment of the smallest domain in turn.
+type decoder struct {
+	display  display
+	patterns []segmentSet
+	found    []wiring
+}
+
+// maxWirings to look for, two are enough to tell the input is ambiguous
+const maxWirings = 2
+
+func (d display) decode(patterns []segmentSet) (wiring, error) {
+	dec := &decoder{display: d, patterns: patterns}
+	domains := make([]segmentSet, d.segments())
+	for i := range domains {
+		domains[i] = d.all()
+	}
+	dec.search(domains)
+
+	switch len(dec.found) {
+	case 0:
+		return nil, fmt.Errorf("inconsistent: no wiring lights up a glyph with every pattern")
+	case 1:
+		return dec.found[0], nil
+	default:
+		return nil, fmt.Errorf("ambiguous: both %s and %s fit", dec.found[0].format(d), dec.found[1].format(d))
+	}
+}
+
+func (dec *decoder) search(domains []segmentSet) {
+	if len(dec.found) >= maxWirings || !dec.propagate(domains) {
+		return
+	}
+
+	// branch on the wire with the fewest candidates
+	branch := -1
+	for wire, dom := range domains {
+		if dom.length() > 1 && (branch < 0 || dom.length() < domains[branch].length()) {
+			branch = wire
+		}
+	}
+	if branch < 0 {
+		dec.accept(domains)
+		return
+	}
+
+	for seg := 0; seg < dec.display.segments(); seg++ {
+		if !domains[branch].has(segment(1 << seg)) {
+			continue
+		}
+		next := append([]segmentSet(nil), domains...)
+		next[branch] = segmentSet(1 << seg)
+		dec.search(next)
+	}
+}
+
+// propagate narrows the domains until nothing changes, false if a wire runs out of segments
+func (dec *decoder) propagate(domains []segmentSet) bool {
+	for changed := true; changed; {
+		changed = false
+		narrow := func(wire int, to segmentSet) {
+			if to != domains[wire] {
+				domains[wire] = to
+				changed = true
+			}
+		}
+
+		for _, pattern := range dec.patterns {
+			// the glyphs the pattern can still be
+			union, common, fits := segmentSet(0), dec.display.all(), 0
+			for _, g := range dec.display.glyphs {
+				if dec.fits(domains, pattern, g.segments) {
+					union |= g.segments
+					common &= g.segments
+					fits++
+				}
+			}
+			if fits == 0 {
+				return false
+			}
+			// a lit wire goes to a segment of one of the glyphs, an unlit one can't go to a segment all of them light
+			for wire := range domains {
+				if pattern.has(segment(1 << wire)) {
+					narrow(wire, domains[wire]&union)
+				} else {
+					narrow(wire, domains[wire]&^common)
+				}
+			}
+		}
+
+		// the wires go to different segments
+		for wire, dom := range domains {
+			if dom == 0 {
+				return false
+			}
+			if dom.length() != 1 {
+				continue
+			}
+			for other := range domains {
+				if other != wire {
+					narrow(other, domains[other]&^dom)
+				}
+			}
+		}
+	}
+	return true
+}
+
+// fits checks the glyph lights up as many segments as the pattern, and every wire can still go where it should
+func (dec *decoder) fits(domains []segmentSet, pattern, g segmentSet) bool {
+	if pattern.length() != g.length() {
+		return false
+	}
+	for wire, dom := range domains {
+		if pattern.has(segment(1 << wire)) {
+			if dom&g == 0 {
+				return false
+			}
+		} else if dom&^g == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// accept records the wiring if every pattern lights up a glyph
+func (dec *decoder) accept(domains []segmentSet) {
+	w := make(wiring, len(domains))
+	for wire, dom := range domains {
+		for seg := 0; seg < dec.display.segments(); seg++ {
+			if dom == segmentSet(1<<seg) {
+				w[wire] = seg
+			}
+		}
+	}
+	for _, pattern := range dec.patterns {
+		if _, ok := dec.display.lookup(w.translate(pattern)); !ok {
+			return
+		}
+	}
+	dec.found = append(dec.found, w)
+}
+
+// declarativeSolve maps the scrambled patterns to the glyphs of the display, like scrambledSegmentsSolver.Solve
+func declarativeSolve(d display, p puzzle) (map[segmentSet]segmentSet, error) {
+	if err := d.validate(p); err != nil {
+		return nil, err
+	}
+	w, err := d.decode(p.wires)
+	if err != nil {
+		return nil, err
+	}
+	solution := map[segmentSet]segmentSet{}
+	for _, pattern := range append(append([]segmentSet(nil), p.wires...), p.digits...) {
+		translated := w.translate(pattern)
+		if _, ok := d.lookup(translated); !ok {
+			return nil, fmt.Errorf("inconsistent: %s is wired to %s, which isn't a glyph",
+				pattern.format(), d.format(translated))
+		}
+		solution[pattern] = translated
+	}
+	return solution, nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// glyph is a symbol of the display, with the segments it lights up
+type glyph struct {
+	symbol   string
+	segments segmentSet
+}
+
+// display is the table of glyphs over its named segments. The i-th segment is wired to the i-th letter of the
+// scrambled input, e.g. g2, the 8th segment of the 14-segment display, to h.
+type display struct {
+	names  []string
+	glyphs []glyph
+}
+
+var sevenSegment = display{
+	names: []string{"a", "b", "c", "d", "e", "f", "g"},
+	glyphs: []glyph{
+		{"0", digit0}, {"1", digit1}, {"2", digit2}, {"3", digit3}, {"4", digit4},
+		{"5", digit5}, {"6", digit6}, {"7", digit7}, {"8", digit8}, {"9", digit9},
+	},
+}
+
+func (d display) segments() int {
+	return len(d.names)
+}
+
+func (d display) all() segmentSet {
+	return segmentSet(1<<d.segments() - 1)
+}
+
+func (d display) lookup(s segmentSet) (glyph, bool) {
+	for _, g := range d.glyphs {
+		if g.segments == s {
+			return g, true
+		}
+	}
+	return glyph{}, false
+}
+
+// format the segments by their names
+func (d display) format(s segmentSet) string {
+	xs := make([]string, 0, s.length())
+	for i, name := range d.names {
+		if s.has(segment(1 << i)) {
+			xs = append(xs, name)
+		}
+	}
+	return "{" + strings.Join(xs, " ") + "}"
+}
+
+// validate checks the puzzle has only the wires of the display
+func (d display) validate(p puzzle) error {
+	for _, pattern := range append(append([]segmentSet(nil), p.wires...), p.digits...) {
+		if pattern&^d.all() != 0 {
+			return fmt.Errorf("%s has wires the %d segments display doesn't", pattern.format(), d.segments())
+		}
+	}
+	return nil
+}
+
+// readDisplay parses the segment names, then a glyph per line, e.g.
+//
+//	# 7-segment display
+//	segments a b c d e f g
+//	1 c f
+//	7 a c f
+func readDisplay(r io.Reader) (display, error) {
+	var d display
+	index := map[string]int{}
+	seen := map[segmentSet]string{}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		xs := strings.Fields(s.Text())
+		if len(xs) == 0 || strings.HasPrefix(xs[0], "#") {
+			continue
+		}
+		if d.names == nil {
+			if xs[0] != "segments" {
+				return display{}, fmt.Errorf("want the segment names first, got %s", s.Text())
+			}
+			if len(xs) < 2 || len(xs)-1 > maxSegments {
+				return display{}, fmt.Errorf("want 1 to %d segments, got %d", maxSegments, len(xs)-1)
+			}
+			for i, name := range xs[1:] {
+				if _, ok := index[name]; ok {
+					return display{}, fmt.Errorf("segment %s named twice", name)
+				}
+				index[name] = i
+			}
+			d.names = xs[1:]
+			continue
+		}
+
+		g := glyph{symbol: xs[0]}
+		for _, name := range xs[1:] {
+			i, ok := index[name]
+			if !ok {
+				return display{}, fmt.Errorf("glyph %s: unknown segment %s", g.symbol, name)
+			}
+			g.segments |= segmentSet(1 << i)
+		}
+		if other, ok := seen[g.segments]; ok {
+			return display{}, fmt.Errorf("glyphs %s and %s light up the same segments", other, g.symbol)
+		}
+		seen[g.segments] = g.symbol
+		d.glyphs = append(d.glyphs, g)
+	}
+	if err := s.Err(); err != nil {
+		return display{}, err
+	}
+	if len(d.glyphs) == 0 {
+		return display{}, fmt.Errorf("no glyphs defined")
+	}
+	return d, nil
+}
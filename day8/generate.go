@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+// outputs on the right of every puzzle
+const outputs = 4
+
+// truth is the known answer to a generated puzzle
+type truth struct {
+	wiring  wiring
+	symbols string
+}
+
+// generate writes n puzzles in the read format, each every glyph of the display scrambled by a random wiring and
+// shuffled, and the wiring with the outputs to the truth writer
+func generate(d display, n int, rnd *rand.Rand, w io.Writer, truthW io.Writer) {
+	for i := 0; i < n; i++ {
+		wires := wiring(rnd.Perm(d.segments()))
+		// the wire of every segment
+		inverse := make([]int, len(wires))
+		for wire, seg := range wires {
+			inverse[seg] = wire
+		}
+		scramble := func(g glyph) string {
+			letters := make([]byte, 0, g.segments.length())
+			for seg := 0; seg < d.segments(); seg++ {
+				if g.segments.has(segment(1 << seg)) {
+					letters = append(letters, segment(1<<inverse[seg]).format())
+				}
+			}
+			rnd.Shuffle(len(letters), func(i, j int) {
+				letters[i], letters[j] = letters[j], letters[i]
+			})
+			return string(letters)
+		}
+
+		patterns := make([]string, 0, len(d.glyphs))
+		for _, k := range rnd.Perm(len(d.glyphs)) {
+			patterns = append(patterns, scramble(d.glyphs[k]))
+		}
+		digits := make([]string, 0, outputs)
+		symbols := make([]string, 0, outputs)
+		for k := 0; k < outputs; k++ {
+			g := d.glyphs[rnd.Intn(len(d.glyphs))]
+			digits = append(digits, scramble(g))
+			symbols = append(symbols, g.symbol)
+		}
+
+		fmt.Fprintf(w, "%s | %s\n", strings.Join(patterns, " "), strings.Join(digits, " "))
+		if truthW != nil {
+			fmt.Fprintf(truthW, "%s | %s\n", wires.format(d), strings.Join(symbols, " "))
+		}
+	}
+}
+
+// readTruth parses a wiring and the outputs per line, as written by generate
+func readTruth(path string, d display) ([]truth, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	index := map[string]int{}
+	for i, name := range d.names {
+		index[name] = i
+	}
+	result := make([]truth, 0)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		xs := strings.SplitN(s.Text(), " | ", 2)
+		if len(xs) != 2 {
+			return nil, fmt.Errorf("line %d: want <wiring> | <outputs>, got %s", len(result)+1, s.Text())
+		}
+		t := truth{wiring: make(wiring, d.segments()), symbols: strings.Join(strings.Fields(xs[1]), "")}
+		pairs := strings.Fields(xs[0])
+		if len(pairs) != d.segments() {
+			return nil, fmt.Errorf("line %d: want a wire per segment, got %d", len(result)+1, len(pairs))
+		}
+		for _, pair := range pairs {
+			wireSeg := strings.SplitN(pair, "->", 2)
+			if len(wireSeg) != 2 || len(wireSeg[0]) != 1 {
+				return nil, fmt.Errorf("line %d: want <wire>-><segment>, got %s", len(result)+1, pair)
+			}
+			wire := int(wireSeg[0][0]) - 'a'
+			seg, ok := index[wireSeg[1]]
+			if !ok || wire < 0 || wire >= d.segments() {
+				return nil, fmt.Errorf("line %d: unknown wire or segment in %s", len(result)+1, pair)
+			}
+			t.wiring[wire] = seg
+		}
+		result = append(result, t)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// verify checks the solution maps every pattern to the glyph the truth wires it to
+func (t truth) verify(d display, line puzzle, solution map[segmentSet]segmentSet) error {
+	symbols := make([]string, 0, len(line.digits))
+	for _, pattern := range append(append([]segmentSet(nil), line.wires...), line.digits...) {
+		if want := t.wiring.translate(pattern); solution[pattern] != want {
+			return fmt.Errorf("%s is %s, the solution says %s",
+				pattern.format(), d.format(want), d.format(solution[pattern]))
+		}
+	}
+	for _, digit := range line.digits {
+		g, _ := d.lookup(solution[digit])
+		symbols = append(symbols, g.symbol)
+	}
+	if got := strings.Join(symbols, ""); got != t.symbols {
+		return fmt.Errorf("the outputs are %s, the solution says %s", t.symbols, got)
+	}
+	return nil
+}
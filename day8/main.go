@@ -2,15 +2,21 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"math/bits"
+	"math/rand"
 	"os"
 	"strings"
+	"time"
 )
 
-type segment uint8
+// segment is a bit of a 32-bit set, a wire is a letter, so a display can have up to 26 of them
+type segment uint32
+
+const maxSegments = 26
 
 const (
 	segmentA segment = 1 << 0
@@ -24,51 +30,22 @@ const (
 
 var segments = []segment{segmentA, segmentB, segmentC, segmentD, segmentE, segmentF, segmentG}
 
+// format the segment as its letter, the segments past g are the wires of the bigger displays
 func (s segment) format() byte {
-	switch s {
-	case segmentA:
-		return 'a'
-	case segmentB:
-		return 'b'
-	case segmentC:
-		return 'c'
-	case segmentD:
-		return 'd'
-	case segmentE:
-		return 'e'
-	case segmentF:
-		return 'f'
-	case segmentG:
-		return 'g'
-	default:
+	if bits.OnesCount32(uint32(s)) != 1 || bits.TrailingZeros32(uint32(s)) >= maxSegments {
 		log.Fatalf("Can't match %b to a segment", s)
-		return ' '
 	}
+	return byte('a' + bits.TrailingZeros32(uint32(s)))
 }
 
 func asSegment(in rune) segment {
-	switch in {
-	case 'a':
-		return segmentA
-	case 'b':
-		return segmentB
-	case 'c':
-		return segmentC
-	case 'd':
-		return segmentD
-	case 'e':
-		return segmentE
-	case 'f':
-		return segmentF
-	case 'g':
-		return segmentG
-	default:
+	if in < 'a' || in >= 'a'+maxSegments {
 		log.Fatalf("Can't match %c to a segment", in)
-		return 0
 	}
+	return segment(1 << (in - 'a'))
 }
 
-type segmentSet uint8
+type segmentSet uint32
 
 const (
 	digit0 = segmentSet(segmentA | segmentB | segmentC | segmentE | segmentF | segmentG)
@@ -111,11 +88,11 @@ func (s segmentSet) value() int {
 }
 
 func (s segmentSet) length() int {
-	return bits.OnesCount8(uint8(s))
+	return bits.OnesCount32(uint32(s))
 }
 
 func (s segmentSet) has(seg segment) bool {
-	if uint8(s)&uint8(seg) > 0 {
+	if uint32(s)&uint32(seg) > 0 {
 		return true
 	}
 	return false
@@ -136,18 +113,18 @@ func (s segmentSet) countSegments() (segment, int) {
 
 func (s segmentSet) format() string {
 	buf := make([]byte, 0, s.length())
-	for _, segment := range segments {
-		if s.has(segment) {
-			buf = append(buf, segment.format())
+	for i := 0; i < maxSegments; i++ {
+		if seg := segment(1 << i); s.has(seg) {
+			buf = append(buf, seg.format())
 		}
 	}
 	return string(buf)
 }
 
 func asSegmentSet(in string) segmentSet {
-	var s uint8
+	var s uint32
 	for _, c := range in {
-		s |= uint8(asSegment(c))
+		s |= uint32(asSegment(c))
 	}
 	return segmentSet(s)
 }
@@ -156,7 +133,7 @@ func diff(a segmentSet, b segmentSet, xs ...segmentSet) segmentSet {
 	result := a
 	items := append([]segmentSet{b}, xs...)
 	for i := 0; i < len(items); i++ {
-		result = segmentSet(uint8(result) ^ uint8(items[i]))
+		result = segmentSet(uint32(result) ^ uint32(items[i]))
 	}
 	return result
 }
@@ -167,6 +144,9 @@ type scrambledSegmentsSolver struct {
 
 	matchedDigits   map[segmentSet]segmentSet
 	matchedSegments map[segment]segment
+
+	// trace gets the deduction steps, if set
+	trace io.Writer
 }
 
 func NewSolver(p puzzle) *scrambledSegmentsSolver {
@@ -186,53 +166,96 @@ func NewSolver(p puzzle) *scrambledSegmentsSolver {
 	}
 }
 
-func (s *scrambledSegmentsSolver) Solve() map[segmentSet]segmentSet {
+func (s *scrambledSegmentsSolver) Solve() (map[segmentSet]segmentSet, error) {
 	// Find easy digits
-	s.findEasyDigits()
+	if err := s.findEasyDigits(); err != nil {
+		return nil, err
+	}
 
 	// Deduce other digits
-	s.deduceOtherDigits()
+	if err := s.deduceOtherDigits(); err != nil {
+		return nil, err
+	}
 
 	// Invert the matched digits (so that we can transform the other way)
 	solution := map[segmentSet]segmentSet{}
 	for k, v := range s.matchedDigits {
 		solution[v] = k
 	}
-	return solution
+	for _, digit := range s.inputDigits {
+		if _, ok := solution[digit]; !ok {
+			return nil, s.fail("%s = ?, the output isn't any of the patterns", digit.format())
+		}
+		s.explain("%s = [%d]", digit.format(), solution[digit].value())
+	}
+	return solution, nil
 }
 
-func (s *scrambledSegmentsSolver) findEasyDigits() {
+// explain writes a deduction step to the trace
+func (s *scrambledSegmentsSolver) explain(format string, args ...interface{}) {
+	if s.trace != nil {
+		fmt.Fprintf(s.trace, "  "+format+"\n", args...)
+	}
+}
+
+// fail marks the step that went wrong in the trace
+func (s *scrambledSegmentsSolver) fail(format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+	s.explain("!! %v", err)
+	return err
+}
+
+func (s *scrambledSegmentsSolver) findEasyDigits() error {
 	unmatchedDigits := make([]segmentSet, 0, 6)
 	for _, wire := range s.inputWires {
+		var digit segmentSet
 		switch wire.length() {
 		case digit1.length():
-			s.matchedDigits[digit1] = wire
+			digit = digit1
 		case digit4.length():
-			s.matchedDigits[digit4] = wire
+			digit = digit4
 		case digit7.length():
-			s.matchedDigits[digit7] = wire
+			digit = digit7
 		case digit8.length():
-			s.matchedDigits[digit8] = wire
+			digit = digit8
 		default:
 			unmatchedDigits = append(unmatchedDigits, wire)
+			continue
+		}
+		if other, ok := s.matchedDigits[digit]; ok {
+			return s.fail("[%d] = %s or %s, both have %d segments", digit.value(), other.format(), wire.format(),
+				digit.length())
+		}
+		s.matchedDigits[digit] = wire
+		s.explain("[%d] = %s, the only digit with %d segments", digit.value(), wire.format(), digit.length())
+	}
+	for _, digit := range []segmentSet{digit1, digit4, digit7, digit8} {
+		if _, ok := s.matchedDigits[digit]; !ok {
+			return s.fail("[%d] = ?, no pattern has %d segments", digit.value(), digit.length())
 		}
 	}
 	s.inputWires = unmatchedDigits
+	return nil
 }
 
-func (s *scrambledSegmentsSolver) deduceOtherDigits() {
+func (s *scrambledSegmentsSolver) deduceOtherDigits() error {
+	var err error
+
 	// Find segment A  (difference between digits 7 and 1)
 	// {A} = [7] - [1]
-	s.matchedSegments[segmentA] = mustOne(
-		"{A}",
+	if s.matchedSegments[segmentA], err = s.one(
+		"{A} = [7] - [1]",
 		diff(
 			s.matchedDigits[digit7],
 			s.matchedDigits[digit1],
 		),
-	)
+	); err != nil {
+		return err
+	}
 
 	// Find digit 3  (like digit 7, but with two extra segments D, G)
 	// [3] - [7] = {D, G}
+	found := false
 	for i := 0; i < len(s.inputWires); i++ {
 		if _, count := diff(
 			s.inputWires[i],
@@ -240,12 +263,18 @@ func (s *scrambledSegmentsSolver) deduceOtherDigits() {
 		).countSegments(); count == 2 {
 			s.matchedDigits[digit3] = s.inputWires[i]
 			s.inputWires = append(s.inputWires[:i], s.inputWires[i+1:]...)
+			found = true
 			break
 		}
 	}
+	if !found {
+		return s.fail("[3] - [7] = {D, G}, no pattern is [7] and two more segments")
+	}
+	s.explain("[3] - [7] = {D, G}, so [3] = %s", s.matchedDigits[digit3].format())
 
 	// Find digit 9 and segment B
 	// [9] - [3] = {B}
+	found = false
 	for i := 0; i < len(s.inputWires); i++ {
 		if got, count := diff(
 			s.inputWires[i],
@@ -254,35 +283,45 @@ func (s *scrambledSegmentsSolver) deduceOtherDigits() {
 			s.matchedDigits[digit9] = s.inputWires[i]
 			s.inputWires = append(s.inputWires[:i], s.inputWires[i+1:]...)
 			s.matchedSegments[segmentB] = got
+			found = true
 			break
 		}
 	}
+	if !found {
+		return s.fail("[9] - [3] = {B}, no pattern is [3] and one more segment")
+	}
+	s.explain("[9] - [3] = {B}, so [9] = %s and {B} = %c",
+		s.matchedDigits[digit9].format(), s.matchedSegments[segmentB].format())
 
 	// Find segment E
 	// {E} = [8] - [9]
-	s.matchedSegments[segmentE] = mustOne(
-		"{E}",
+	if s.matchedSegments[segmentE], err = s.one(
+		"{E} = [8] - [9]",
 		diff(
 			s.matchedDigits[digit8],
 			s.matchedDigits[digit9],
 		),
-	)
+	); err != nil {
+		return err
+	}
 
 	// Find segment D
 	// {D} = [4] - [1] - B
-	s.matchedSegments[segmentD] = mustOne(
-		"{D}",
+	if s.matchedSegments[segmentD], err = s.one(
+		"{D} = [4] - [1] - {B}",
 		diff(
 			s.matchedDigits[digit4],
 			s.matchedDigits[digit1],
 			segmentSet(s.matchedSegments[segmentB]),
 		),
-	)
+	); err != nil {
+		return err
+	}
 
 	// Find segment G
 	// {G} = [8] - [1] - {B} - {D} - {E}
-	s.matchedSegments[segmentG] = mustOne(
-		"{G}",
+	if s.matchedSegments[segmentG], err = s.one(
+		"{G} = [8] - [1] - {A} - {B} - {D} - {E}",
 		diff(
 			s.matchedDigits[digit8],
 			s.matchedDigits[digit1],
@@ -291,10 +330,13 @@ func (s *scrambledSegmentsSolver) deduceOtherDigits() {
 			segmentSet(s.matchedSegments[segmentD]),
 			segmentSet(s.matchedSegments[segmentE]),
 		),
-	)
+	); err != nil {
+		return err
+	}
 
 	// Find digit 6 and segment F
 	// {F} = [6] - {A} - {B} - {D} - {E} - {G}
+	found = false
 	for i := 0; i < len(s.inputWires); i++ {
 		if got, count := diff(
 			s.inputWires[i],
@@ -307,22 +349,31 @@ func (s *scrambledSegmentsSolver) deduceOtherDigits() {
 			s.matchedDigits[digit6] = s.inputWires[i]
 			s.inputWires = append(s.inputWires[:i], s.inputWires[i+1:]...)
 			s.matchedSegments[segmentF] = got
+			found = true
 			break
 		}
 	}
+	if !found {
+		return s.fail("{F} = [6] - {A} - {B} - {D} - {E} - {G}, no pattern is {A, B, D, E, G} and one more segment")
+	}
+	s.explain("{F} = [6] - {A} - {B} - {D} - {E} - {G}, so [6] = %s and {F} = %c",
+		s.matchedDigits[digit6].format(), s.matchedSegments[segmentF].format())
 
 	// Find segment C
 	// {C} = [1] - {F}
-	s.matchedSegments[segmentC] = mustOne(
-		"{C}",
+	if s.matchedSegments[segmentC], err = s.one(
+		"{C} = [1] - {F}",
 		diff(
 			s.matchedDigits[digit1],
 			segmentSet(s.matchedSegments[segmentF]),
 		),
-	)
+	); err != nil {
+		return err
+	}
 
 	// Find digit 5
 	// {5} = {6} - {E}
+	found = false
 	for i := 0; i < len(s.inputWires); i++ {
 		if diff(
 			s.inputWires[i],
@@ -330,12 +381,19 @@ func (s *scrambledSegmentsSolver) deduceOtherDigits() {
 		) == s.matchedDigits[digit6] {
 			s.matchedDigits[digit5] = s.inputWires[i]
 			s.inputWires = append(s.inputWires[:i], s.inputWires[i+1:]...)
+			found = true
 			break
 		}
 	}
+	if !found {
+		return s.fail("[5] = [6] - {E} = %s, no such pattern",
+			diff(s.matchedDigits[digit6], segmentSet(s.matchedSegments[segmentE])).format())
+	}
+	s.explain("[5] = [6] - {E} = %s", s.matchedDigits[digit5].format())
 
 	// Find digit 0
 	// [0] = [8] - {D}
+	found = false
 	for i := 0; i < len(s.inputWires); i++ {
 		if diff(
 			s.matchedDigits[digit8],
@@ -343,47 +401,139 @@ func (s *scrambledSegmentsSolver) deduceOtherDigits() {
 		) == s.inputWires[i] {
 			s.matchedDigits[digit0] = s.inputWires[i]
 			s.inputWires = append(s.inputWires[:i], s.inputWires[i+1:]...)
+			found = true
 			break
 		}
 	}
+	if !found {
+		return s.fail("[0] = [8] - {D} = %s, no such pattern",
+			diff(s.matchedDigits[digit8], segmentSet(s.matchedSegments[segmentD])).format())
+	}
+	s.explain("[0] = [8] - {D} = %s", s.matchedDigits[digit0].format())
 
 	// Find digit 2
 	// The last remaining digit
+	if len(s.inputWires) == 0 {
+		return s.fail("[2] = ?, no pattern left")
+	}
 	s.matchedDigits[digit2] = s.inputWires[0]
 	s.inputWires = s.inputWires[1:]
+	s.explain("[2] = %s, the last pattern left", s.matchedDigits[digit2].format())
 
 	// Assert that we've processed the input and have the solution
 	if len(s.inputWires) > 0 {
-		log.Fatalf("Left with input to process: %v", s.inputWires)
+		return s.fail("left with input to process: %v", s.inputWires)
 	}
 	if len(s.matchedDigits) != 10 {
-		log.Fatalf("Want all 10 digits, got: %v", s.matchedDigits)
+		return s.fail("want all 10 digits, got: %v", s.matchedDigits)
 	}
 	if len(s.matchedSegments) != 7 {
-		log.Fatalf("Want all 7 digits, got: %v", s.matchedSegments)
+		return s.fail("want all 7 segments, got: %v", s.matchedSegments)
 	}
+	return nil
 }
 
-func mustOne(desc string, s segmentSet) segment {
-	if got, count := s.countSegments(); count == 1 {
-		return got
+// one checks the step gives a single segment
+func (s *scrambledSegmentsSolver) one(step string, set segmentSet) (segment, error) {
+	got, count := set.countSegments()
+	if count != 1 {
+		return 0, s.fail("%s = {%s}, want a single segment", step, set.format())
 	}
-	panic(fmt.Errorf("the segment set %s: %0b contains more than a single segment", desc, s))
-	return 0
+	s.explain("%s = %c", step, got.format())
+	return got, nil
 }
 
 func main() {
+	solver := flag.String("solver", "rules", "how to unscramble the wires: rules (hand-derived) or declarative")
+	check := flag.Bool("check", false, "cross-validate the two solvers")
+	displayFile := flag.String("display", "", "file with the segments and glyphs of another display, decoded declaratively")
+	explain := flag.Bool("explain", false, "print the deduction steps of the rules for every line")
+	puzzles := flag.Int("generate", 0, "write this many random puzzles instead of solving")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed of the generator, the same seed gives the same puzzles")
+	truthFile := flag.String("truth", "", "where the generator writes the wirings and outputs, checked against when solving")
+	flag.Parse()
+
+	d := sevenSegment
+	if *displayFile != "" {
+		f, err := os.Open(*displayFile)
+		if err != nil {
+			log.Fatalf("Can't open %s: %v\n", *displayFile, err)
+		}
+		d, err = readDisplay(f)
+		_ = f.Close()
+		if err != nil {
+			log.Fatalf("Can't read the display: %v\n", err)
+		}
+	}
+
+	if *puzzles > 0 {
+		writeGenerated(d, *puzzles, *seed, *truthFile)
+		return
+	}
+
 	lines := read()
+	var truths []truth
+	if *truthFile != "" {
+		var err error
+		if truths, err = readTruth(*truthFile, d); err != nil {
+			log.Fatalf("Can't read the truth: %v\n", err)
+		}
+		if len(truths) != len(lines) {
+			log.Fatalf("Want the truth of all %d lines, got %d\n", len(lines), len(truths))
+		}
+	}
+	verify := func(n int, line puzzle, solution map[segmentSet]segmentSet) {
+		if truths == nil {
+			return
+		}
+		if err := truths[n].verify(d, line, solution); err != nil {
+			log.Fatalf("Line %d: %s: wrong solution: %v\n", n+1, line.format(), err)
+		}
+	}
+
+	if *displayFile != "" {
+		decodeAll(d, lines, verify)
+		return
+	}
+
+	if *explain && *solver != "rules" {
+		log.Fatalf("Only the rules can explain their deduction\n")
+	}
 
 	countPart1 := 0
 	sumPart2 := 0
-	for _, line := range lines {
-		if len(lines) < 20 {
+	for n, line := range lines {
+		if len(lines) < 20 || *explain {
 			fmt.Printf("%s\n", line.format())
 		}
 
-		solver := NewSolver(line)
-		solution := solver.Solve()
+		var solution map[segmentSet]segmentSet
+		switch *solver {
+		case "rules":
+			if err := sevenSegment.validate(line); err != nil {
+				log.Fatalf("Can't decode line %d: %v\n", n+1, err)
+			}
+			rules := NewSolver(line)
+			if *explain {
+				rules.trace = os.Stdout
+			}
+			var err error
+			if solution, err = rules.Solve(); err != nil {
+				log.Fatalf("Can't decode line %d: %s: %v\n", n+1, line.format(), err)
+			}
+		case "declarative":
+			var err error
+			if solution, err = declarativeSolve(sevenSegment, line); err != nil {
+				log.Fatalf("Can't decode line %d: %s: %v\n", n+1, line.format(), err)
+			}
+		default:
+			log.Fatalf("Unknown solver: %s\n", *solver)
+		}
+
+		if *check {
+			crossValidate(n, line)
+		}
+		verify(n, line, solution)
 
 		for i, digit := range line.digits {
 			switch solution[digit] {
@@ -408,6 +558,63 @@ func main() {
 	}
 }
 
+func crossValidate(n int, line puzzle) {
+	want, err := NewSolver(line).Solve()
+	if err != nil {
+		log.Fatalf("Line %d: the rules fail: %v\n", n+1, err)
+	}
+	got, err := declarativeSolve(sevenSegment, line)
+	if err != nil {
+		log.Fatalf("Line %d: the rules solve it, the declarative solver: %v\n", n+1, err)
+	}
+	for _, digit := range line.digits {
+		if want[digit] != got[digit] {
+			log.Fatalf("Line %d: the rules decode %s as %d, the declarative solver as %d\n",
+				n+1, digit.format(), want[digit].value(), got[digit].value())
+		}
+	}
+}
+
+// decodeAll prints the glyphs every line of a custom display shows
+func decodeAll(d display, lines []puzzle, verify func(int, puzzle, map[segmentSet]segmentSet)) {
+	for n, line := range lines {
+		solution, err := declarativeSolve(d, line)
+		if err != nil {
+			log.Fatalf("Can't decode line %d: %s: %v\n", n+1, line.format(), err)
+		}
+		verify(n, line, solution)
+		symbols := make([]string, 0, len(line.digits))
+		for _, digit := range line.digits {
+			g, _ := d.lookup(solution[digit])
+			symbols = append(symbols, g.symbol)
+		}
+		fmt.Printf("%s => %s\n", line.format(), strings.Join(symbols, ""))
+	}
+}
+
+func writeGenerated(d display, n int, seed int64, truthFile string) {
+	out := bufio.NewWriter(os.Stdout)
+	if truthFile == "" {
+		generate(d, n, rand.New(rand.NewSource(seed)), out, nil)
+	} else {
+		f, err := os.Create(truthFile)
+		if err != nil {
+			log.Fatalf("Can't create %s: %v\n", truthFile, err)
+		}
+		truthOut := bufio.NewWriter(f)
+		generate(d, n, rand.New(rand.NewSource(seed)), out, truthOut)
+		if err := truthOut.Flush(); err != nil {
+			log.Fatalf("Can't write %s: %v\n", truthFile, err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatalf("Can't write %s: %v\n", truthFile, err)
+		}
+	}
+	if err := out.Flush(); err != nil {
+		log.Fatalf("Can't write the puzzles: %v\n", err)
+	}
+}
+
 // boring input read
 type puzzle struct {
 	wires  []segmentSet
@@ -457,10 +664,10 @@ func read() []puzzle {
 }
 
 func selectInput() (reader io.Reader, closer func()) {
-	if len(os.Args) > 1 {
-		f, err := os.Open(os.Args[1])
+	if flag.NArg() > 0 {
+		f, err := os.Open(flag.Arg(0))
 		if err != nil {
-			log.Fatalf("Can't open %s: %v\n", os.Args[1], err)
+			log.Fatalf("Can't open %s: %v\n", flag.Arg(0), err)
 		}
 		return f, func() {
 			_ = f.Close()